@@ -0,0 +1,299 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// The heapdump command lists the deadlocked goroutines found in a heap
+// dump written by runtime/debug.WriteHeapDump.
+//
+// Usage:
+//
+//	go run ./heapdump [-all] dumpfile
+//
+// A goroutine is deadlocked if its status is unreachable or deadlocked,
+// i.e., if the GC found it blocked forever under GODEBUG=gcdetectdeadlocks.
+// Each one is printed with its wait reason, the objects it is blocked on,
+// if the dump was written with deadlock detection enabled, and its stack.
+// With -all, every blocked goroutine is listed.
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+)
+
+var all = flag.Bool("all", false, "list all blocked goroutines, not just deadlocked ones")
+
+// Record tags, from runtime/heapdump.go.
+const (
+	tagEOF             = 0
+	tagObject          = 1
+	tagOtherRoot       = 2
+	tagType            = 3
+	tagGoroutine       = 4
+	tagStackFrame      = 5
+	tagParams          = 6
+	tagFinalizer       = 7
+	tagItab            = 8
+	tagOSThread        = 9
+	tagMemStats        = 10
+	tagQueuedFinalizer = 11
+	tagData            = 12
+	tagBSS             = 13
+	tagDefer           = 14
+	tagPanic           = 15
+	tagMemProf         = 16
+	tagAllocSample     = 17
+	tagGoroutineWait   = 18
+
+	fieldKindEol = 0
+)
+
+// Goroutine statuses, from runtime/runtime2.go.
+var statusStrings = [...]string{
+	0:  "idle",
+	1:  "runnable",
+	2:  "running",
+	3:  "syscall",
+	4:  "waiting",
+	6:  "dead",
+	8:  "copystack",
+	9:  "preempted",
+	10: "unreachable",
+	11: "deadlocked",
+}
+
+const (
+	statusWaiting     = 4
+	statusUnreachable = 10
+	statusDeadlocked  = 11
+)
+
+type goroutine struct {
+	addr       uint64
+	id         uint64
+	status     uint64
+	waitReason string
+	frames     []string
+
+	// From the tagGoroutineWait record, if any.
+	sema, notifier uint64
+	chans          []uint64
+}
+
+func (g *goroutine) deadlocked() bool {
+	return g.status == statusUnreachable || g.status == statusDeadlocked
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("heapdump: ")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: heapdump [-all] dumpfile\n")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+	}
+
+	f, err := os.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	gs, err := readGoroutines(bufio.NewReader(f))
+	if err != nil {
+		log.Fatalf("%s: %v", flag.Arg(0), err)
+	}
+
+	n := 0
+	for _, g := range gs {
+		if !g.deadlocked() && !(*all && g.status == statusWaiting) {
+			continue
+		}
+		n++
+		status := "?"
+		if g.status < uint64(len(statusStrings)) && statusStrings[g.status] != "" {
+			status = statusStrings[g.status]
+		}
+		fmt.Printf("goroutine %d [%s, %s]:\n", g.id, g.waitReason, status)
+		if g.sema != 0 {
+			fmt.Printf("\twaiting on semaphore %#x\n", g.sema)
+		}
+		if g.notifier != 0 {
+			fmt.Printf("\twaiting on notify list %#x\n", g.notifier)
+		}
+		for _, c := range g.chans {
+			fmt.Printf("\twaiting on channel %#x\n", c)
+		}
+		for _, fn := range g.frames {
+			fmt.Printf("%s(...)\n", fn)
+		}
+		fmt.Println()
+	}
+	if n == 0 && !*all {
+		fmt.Println("no deadlocked goroutines")
+	}
+}
+
+// dumpReader reads the fields of heap dump records.
+type dumpReader struct {
+	r   *bufio.Reader
+	err error
+}
+
+func (d *dumpReader) uint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		d.err = err
+	}
+	return v
+}
+
+func (d *dumpReader) bytes() []byte {
+	n := d.uint()
+	if d.err != nil {
+		return nil
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(d.r, b); err != nil {
+		d.err = err
+		return nil
+	}
+	return b
+}
+
+func (d *dumpReader) string() string {
+	return string(d.bytes())
+}
+
+func (d *dumpReader) skip(n int) {
+	for i := 0; i < n; i++ {
+		d.uint()
+	}
+}
+
+// fields skips a field list.
+func (d *dumpReader) fields() {
+	for d.err == nil && d.uint() != fieldKindEol {
+		d.uint() // offset
+	}
+}
+
+const header = "go1.7 heap dump\n"
+
+// readGoroutines returns the goroutines recorded in a heap dump,
+// in dump order.
+func readGoroutines(r *bufio.Reader) ([]*goroutine, error) {
+	hdr := make([]byte, len(header))
+	if _, err := io.ReadFull(r, hdr); err != nil || string(hdr) != header {
+		return nil, errors.New("not a heap dump")
+	}
+
+	d := &dumpReader{r: r}
+	var gs []*goroutine
+	var cur *goroutine // goroutine the following stack frames belong to
+	byAddr := make(map[uint64]*goroutine)
+	for d.err == nil {
+		tag := d.uint()
+		if tag != tagStackFrame {
+			cur = nil
+		}
+		switch tag {
+		case tagEOF:
+			return gs, nil
+		case tagObject:
+			d.uint() // address
+			d.bytes()
+			d.fields()
+		case tagOtherRoot:
+			d.string()
+			d.uint()
+		case tagType:
+			d.skip(2) // address, size
+			d.string()
+			d.uint() // indirect
+		case tagGoroutine:
+			g := &goroutine{addr: d.uint()}
+			d.uint() // sp
+			g.id = d.uint()
+			d.uint() // gopc
+			g.status = d.uint()
+			d.skip(3) // system, background, waitsince
+			g.waitReason = d.string()
+			d.skip(4) // ctxt, m, defer, panic
+			gs = append(gs, g)
+			byAddr[g.addr] = g
+			cur = g
+		case tagStackFrame:
+			d.skip(3) // sp, depth, child sp
+			d.bytes()
+			d.skip(3) // entry, pc, continpc
+			name := d.string()
+			d.fields()
+			if cur != nil {
+				cur.frames = append(cur.frames, name)
+			}
+		case tagParams:
+			d.skip(4)  // endianness, pointer size, arena start and end
+			d.string() // GOARCH
+			d.string() // version
+			d.uint()   // ncpu
+		case tagFinalizer, tagQueuedFinalizer:
+			d.skip(5)
+		case tagItab:
+			d.skip(2)
+		case tagOSThread:
+			d.skip(3)
+		case tagMemStats:
+			d.skip(24 + 256 + 1)
+		case tagData, tagBSS:
+			d.uint() // address
+			d.bytes()
+			d.fields()
+		case tagDefer:
+			d.skip(7)
+		case tagPanic:
+			d.skip(6)
+		case tagMemProf:
+			d.skip(2) // bucket, size
+			nstk := d.uint()
+			for i := uint64(0); i < nstk && d.err == nil; i++ {
+				d.string() // function
+				d.string() // file
+				d.uint()   // line
+			}
+			d.skip(2) // allocs, frees
+		case tagAllocSample:
+			d.skip(2)
+		case tagGoroutineWait:
+			g := byAddr[d.uint()]
+			sema, notifier := d.uint(), d.uint()
+			nchans := d.uint()
+			var chans []uint64
+			for i := uint64(0); i < nchans && d.err == nil; i++ {
+				chans = append(chans, d.uint())
+			}
+			if g != nil {
+				g.sema, g.notifier, g.chans = sema, notifier, chans
+			}
+		default:
+			return nil, fmt.Errorf("unknown record tag %d", tag)
+		}
+	}
+	if d.err == io.EOF {
+		d.err = io.ErrUnexpectedEOF
+	}
+	return nil, d.err
+}
@@ -0,0 +1,144 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	if dump := os.Getenv("GO_HEAPDUMP_TEST_DUMP"); dump != "" {
+		writeDump(dump)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// reachable keeps the channel reachableRecv is blocked on reachable.
+var reachable = make(chan struct{})
+
+func reachableRecv(started chan<- struct{}) {
+	close(started)
+	<-reachable
+}
+
+func deadlockedRecv(started chan<- struct{}) {
+	close(started)
+	<-make(chan struct{})
+}
+
+// paddedMutex is not allocated by the tiny allocator, so that
+// goroutines blocked on it cannot be kept alive by unrelated objects
+// sharing its memory block.
+type paddedMutex struct {
+	sync.Mutex
+	_ [16]byte
+}
+
+func deadlockedLock(started chan<- struct{}) {
+	mu := new(paddedMutex)
+	mu.Lock()
+	close(started)
+	mu.Lock()
+}
+
+// writeDump writes a heap dump to the named file, once the goroutines
+// blocked forever in deadlockedRecv and deadlockedLock are found deadlocked.
+// It must run under GODEBUG=gcdetectdeadlocks=2, which keeps them alive.
+func writeDump(name string) {
+	for _, f := range []func(chan<- struct{}){reachableRecv, deadlockedRecv, deadlockedLock} {
+		started := make(chan struct{})
+		go f(started)
+		<-started
+	}
+	for len(debug.DeadlockedGoroutines()) < 2 {
+		time.Sleep(time.Millisecond)
+		runtime.GC()
+	}
+
+	f, err := os.Create(name)
+	if err != nil {
+		panic(err)
+	}
+	debug.WriteHeapDump(f.Fd())
+	if err := f.Close(); err != nil {
+		panic(err)
+	}
+}
+
+func TestReadGoroutines(t *testing.T) {
+	if runtime.GOOS == "js" || runtime.GOOS == "wasip1" {
+		t.Skipf("cannot exec subprocess on %s", runtime.GOOS)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dump := filepath.Join(t.TempDir(), "dump")
+	cmd := exec.Command(exe, "-test.run=^$")
+	cmd.Env = append(os.Environ(), "GODEBUG=gcdetectdeadlocks=2", "GO_HEAPDUMP_TEST_DUMP="+dump)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("writing heap dump: %v\n%s", err, out)
+	}
+
+	f, err := os.Open(dump)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gs, err := readGoroutines(bufio.NewReader(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byFunc := make(map[string]*goroutine)
+	for _, g := range gs {
+		for _, fn := range g.frames {
+			byFunc[fn] = g
+		}
+	}
+	for _, tt := range []struct {
+		fn         string
+		waitReason string
+		deadlocked bool
+		sema       bool
+		chans      int
+	}{
+		{"misc/heapdump.reachableRecv", "chan receive", false, false, 1},
+		{"misc/heapdump.deadlockedRecv", "chan receive", true, false, 1},
+		{"misc/heapdump.deadlockedLock", "sync.Mutex.Lock", true, true, 0},
+	} {
+		g := byFunc[tt.fn]
+		if g == nil {
+			t.Errorf("no goroutine running %s in heap dump", tt.fn)
+			continue
+		}
+		if g.waitReason != tt.waitReason {
+			t.Errorf("%s: wait reason %q, want %q", tt.fn, g.waitReason, tt.waitReason)
+		}
+		if g.deadlocked() != tt.deadlocked {
+			t.Errorf("%s: deadlocked = %v (status %d), want %v", tt.fn, g.deadlocked(), g.status, tt.deadlocked)
+		}
+		if (g.sema != 0) != tt.sema {
+			t.Errorf("%s: waiting on semaphore %#x, want semaphore: %v", tt.fn, g.sema, tt.sema)
+		}
+		if len(g.chans) != tt.chans {
+			t.Errorf("%s: waiting on channels %#x, want %d channels", tt.fn, g.chans, tt.chans)
+		}
+		for _, c := range g.chans {
+			if c == 0 {
+				t.Errorf("%s: waiting on nil channel", tt.fn)
+			}
+		}
+	}
+}
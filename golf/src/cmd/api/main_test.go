@@ -1132,7 +1132,13 @@ func needApproval(filename string) bool {
 		return false
 	}
 	minor := strings.TrimSuffix(strings.TrimPrefix(name, "go1."), ".txt")
+	minor, fork, _ := strings.Cut(minor, ".")
 	n, err := strconv.Atoi(minor)
+	if err == nil && fork != "" {
+		// API files of forks, such as go1.22.golf.txt, list features
+		// that were not added through Go proposals.
+		return false
+	}
 	if err != nil {
 		log.Fatalf("unexpected api file: %v", name)
 	}
@@ -23,6 +23,7 @@ var passFlagToTest = map[string]bool{
 	"fuzz":                 true,
 	"fuzzminimizetime":     true,
 	"fuzztime":             true,
+	"leakcheck":            true,
 	"list":                 true,
 	"memprofile":           true,
 	"memprofilerate":       true,
@@ -55,6 +56,7 @@ var passAnalyzersToVet = map[string]bool{
 	"directive":        true,
 	"errorsas":         true,
 	"framepointer":     true,
+	"goroutineleak":    true,
 	"httpresponse":     true,
 	"ifaceassert":      true,
 	"loopclosure":      true,
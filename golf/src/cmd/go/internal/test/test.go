@@ -277,6 +277,11 @@ control the execution of any test:
 	    Log verbose output and test results in JSON. This presents the
 	    same information as the -v flag in a machine-readable format.
 
+	-leakcheck
+	    Run tests with partial deadlock detection enabled, and fail each test
+	    that leaves goroutines deadlocked once it and its cleanups finish.
+	    The deadlocked goroutines are reported with the test failure.
+
 	-list regexp
 	    List tests, benchmarks, fuzz tests, or examples matching the regular
 	    expression. No tests, benchmarks, fuzz tests, or examples will be run.
@@ -51,6 +51,7 @@ func init() {
 	cf.Bool("failfast", false, "")
 	cf.StringVar(&testFuzz, "fuzz", "", "")
 	cf.Bool("fullpath", false, "")
+	cf.Bool("leakcheck", false, "")
 	cf.StringVar(&testList, "list", "", "")
 	cf.StringVar(&testMemProfile, "memprofile", "", "")
 	cf.String("memprofilerate", "", "")
@@ -40,6 +40,7 @@ To list the available checks, run "go tool vet help":
 	directive        check Go toolchain directives such as //go:debug
 	errorsas         report passing non-pointer or non-error values to errors.As
 	framepointer     report assembly that clobbers the frame pointer before saving it
+	goroutineleak    report goroutines that may block forever on channels
 	httpresponse     check for mistakes using HTTP responses
 	ifaceassert      detect impossible interface-to-interface type assertions
 	loopclosure      check references to loop variables from within nested functions
@@ -62,11 +63,13 @@ To list the available checks, run "go tool vet help":
 
 For details and flags of a particular check, such as printf, run "go tool vet help printf".
 
-By default, all checks are performed.
+By default, all checks but goroutineleak are performed.
 If any flags are explicitly set to true, only those tests are run.
 Conversely, if any flag is explicitly set to false, only those tests are disabled.
 Thus -printf=true runs the printf check,
 and -printf=false runs all checks except the printf check.
+Since goroutineleak reports goroutines that may leak, rather than mistakes,
+it only runs when requested, as in -goroutineleak.
 
 For information on writing a new check, see golang.org/x/tools/go/analysis.
 
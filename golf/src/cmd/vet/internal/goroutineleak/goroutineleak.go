@@ -0,0 +1,758 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package goroutineleak defines an Analyzer that reports goroutines
+// that may block forever on channel operations.
+package goroutineleak
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const Doc = `report goroutines that may block forever on channels
+
+This checker reports common patterns of goroutine leaks, where a
+goroutine blocks forever because no other goroutine is left to
+communicate with it. It only considers channels that do not escape
+the function that makes them, so that all operations on them are
+known. The patterns are:
+
+A goroutine sends on an unbuffered channel that the function starting it
+receives from at most once, after the function may already have returned,
+or in a select statement that may choose another case:
+
+	ch := make(chan T)
+	go func() { ch <- compute() }()
+	select {
+	case v := <-ch:
+		use(v)
+	case <-ctx.Done(): // the sender leaks
+	}
+
+Several goroutines started in a loop send on an unbuffered channel
+that is only received from once, so all but one of them leak.
+
+A goroutine ranges over a channel that is never closed.
+
+A function sends on a channel parameter in an if statement that does
+not return, and then sends on it again, which leaks the sender if the
+caller only expects one value.
+
+A method starts a goroutine that only exits once another method
+of the same type closes a channel, and a function calls the former
+method on a local variable but never calls the latter.
+The pairing of such methods is exported as a fact, so that calls
+in other packages are checked as well.
+
+A buffered channel with enough room for all sends removes the leak,
+as does closing a channel that a goroutine ranges over.`
+
+var Analyzer = &analysis.Analyzer{
+	Name:      "goroutineleak",
+	Doc:       Doc,
+	URL:       "https://pkg.go.dev/cmd/vet",
+	Requires:  []*analysis.Analyzer{inspect.Analyzer},
+	Run:       run,
+	FactTypes: []analysis.Fact{new(stopFact)},
+}
+
+// A stopFact is associated with a method that starts a goroutine
+// which only exits once the method named Stop is called.
+type stopFact struct {
+	Stop string
+}
+
+func (*stopFact) AFact()           {}
+func (f *stopFact) String() string { return "stop:" + f.Stop }
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	// Export the facts about methods of this package first,
+	// so that calls to them are checked below.
+	var methods []*ast.FuncDecl
+	inspect.Preorder([]ast.Node{(*ast.FuncDecl)(nil)}, func(n ast.Node) {
+		if decl := n.(*ast.FuncDecl); decl.Recv != nil && decl.Body != nil {
+			methods = append(methods, decl)
+		}
+	})
+	exportStopFacts(pass, methods)
+
+	inspect.Preorder([]ast.Node{
+		(*ast.FuncDecl)(nil),
+		(*ast.FuncLit)(nil),
+	}, func(n ast.Node) {
+		var body *ast.BlockStmt
+		var typ *ast.FuncType
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			body, typ = n.Body, n.Type
+		case *ast.FuncLit:
+			body, typ = n.Body, n.Type
+		}
+		if body == nil {
+			return
+		}
+		checkChannels(pass, body)
+		checkDoubleSends(pass, typ, body)
+		checkStops(pass, body)
+	})
+	return nil, nil
+}
+
+// inspectStack is like ast.Inspect, but also passes f the stack of
+// nodes enclosing n, from root to the parent of n.
+func inspectStack(root ast.Node, f func(n ast.Node, stack []ast.Node) bool) {
+	var stack []ast.Node
+	ast.Inspect(root, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		if !f(n, stack) {
+			return false
+		}
+		stack = append(stack, n)
+		return true
+	})
+}
+
+// context returns the go statement running the function literal that
+// encloses the top of stack, if it is the outermost function literal
+// in stack, and whether the top of stack is in the body of stack[0]
+// itself, or in such a go statement. It returns ok == false for nodes
+// in other function literals, which may run at any time.
+func context(stack []ast.Node) (g *ast.GoStmt, ok bool) {
+	for i, n := range stack {
+		lit, isLit := n.(*ast.FuncLit)
+		if !isLit {
+			continue
+		}
+		if g != nil || i < 2 {
+			return nil, false
+		}
+		if call, isCall := stack[i-1].(*ast.CallExpr); isCall && call.Fun == lit {
+			if gs, isGo := stack[i-2].(*ast.GoStmt); isGo && gs.Call == call {
+				g = gs
+				continue
+			}
+		}
+		return nil, false
+	}
+	return g, true
+}
+
+// inLoop reports whether the top of stack is in a loop, not counting
+// loops outside of the innermost function literal.
+func inLoop(stack []ast.Node) bool {
+	for i := len(stack) - 1; i >= 0; i-- {
+		switch stack[i].(type) {
+		case *ast.ForStmt, *ast.RangeStmt:
+			return true
+		case *ast.FuncLit:
+			return false
+		}
+	}
+	return false
+}
+
+// A chanOp is an operation on a channel.
+type chanOp struct {
+	node   ast.Node        // *ast.SendStmt, *ast.UnaryExpr or *ast.RangeStmt
+	g      *ast.GoStmt     // goroutine performing the operation, or nil for the function itself
+	sel    *ast.SelectStmt // select statement the operation is a case of, if any
+	inLoop bool
+}
+
+// A localChan describes a channel made by a function and
+// the operations on it.
+type localChan struct {
+	v          *types.Var
+	unbuffered bool
+	escapes    bool // used other than by channel operations, len or cap
+	closed     bool
+	sends      []chanOp
+	recvs      []chanOp // receives and range loops
+}
+
+// checkChannels reports goroutines leaked by sending on or ranging
+// over channels made in body.
+func checkChannels(pass *analysis.Pass, body *ast.BlockStmt) {
+	info := pass.TypesInfo
+	chans := make(map[*types.Var]*localChan)
+	var order []*localChan
+	var returns []*ast.ReturnStmt
+	goInLoop := make(map[*ast.GoStmt]bool)
+
+	inspectStack(body, func(n ast.Node, stack []ast.Node) bool {
+		g, ok := context(stack)
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if n.Tok != token.DEFINE || len(n.Lhs) != len(n.Rhs) || g != nil || !ok {
+				break
+			}
+			for i, lhs := range n.Lhs {
+				if id, isIdent := lhs.(*ast.Ident); isIdent {
+					if v, isVar := info.Defs[id].(*types.Var); isVar {
+						if c := makeChan(info, v, n.Rhs[i]); c != nil {
+							chans[v] = c
+							order = append(order, c)
+						}
+					}
+				}
+			}
+		case *ast.ValueSpec:
+			if len(n.Names) != len(n.Values) || g != nil || !ok {
+				break
+			}
+			for i, id := range n.Names {
+				if v, isVar := info.Defs[id].(*types.Var); isVar {
+					if c := makeChan(info, v, n.Values[i]); c != nil {
+						chans[v] = c
+						order = append(order, c)
+					}
+				}
+			}
+		case *ast.ReturnStmt:
+			if g == nil && ok {
+				returns = append(returns, n)
+			}
+		case *ast.GoStmt:
+			if g == nil && ok {
+				goInLoop[n] = inLoop(stack)
+			}
+		case *ast.Ident:
+			v, isVar := info.Uses[n].(*types.Var)
+			if !isVar || chans[v] == nil {
+				break
+			}
+			c := chans[v]
+			if !ok {
+				c.escapes = true
+				break
+			}
+			op := chanOp{g: g, inLoop: inLoop(stack)}
+			switch parent := stack[len(stack)-1].(type) {
+			case *ast.SendStmt:
+				if parent.Chan != n {
+					c.escapes = true
+					break
+				}
+				op.node = parent
+				op.sel = selectOf(stack[:len(stack)-1], parent)
+				c.sends = append(c.sends, op)
+			case *ast.UnaryExpr:
+				if parent.Op != token.ARROW {
+					c.escapes = true
+					break
+				}
+				op.node = parent
+				if len(stack) >= 2 {
+					if stmt, isStmt := stack[len(stack)-2].(ast.Stmt); isStmt {
+						op.sel = selectOf(stack[:len(stack)-2], stmt)
+					}
+				}
+				c.recvs = append(c.recvs, op)
+			case *ast.RangeStmt:
+				if parent.X != n {
+					c.escapes = true
+					break
+				}
+				op.node = parent
+				c.recvs = append(c.recvs, op)
+			case *ast.CallExpr:
+				switch builtinName(info, parent) {
+				case "close":
+					c.closed = true
+				case "len", "cap":
+				default:
+					c.escapes = true
+				}
+			default:
+				c.escapes = true
+			}
+		}
+		return true
+	})
+
+	for _, c := range order {
+		if c.escapes {
+			continue
+		}
+		checkRanges(pass, c)
+		if c.unbuffered {
+			checkSends(pass, c, returns, goInLoop)
+		}
+	}
+}
+
+// makeChan returns a localChan for v if x makes a channel.
+func makeChan(info *types.Info, v *types.Var, x ast.Expr) *localChan {
+	call, ok := x.(*ast.CallExpr)
+	if !ok || builtinName(info, call) != "make" {
+		return nil
+	}
+	if _, ok := v.Type().Underlying().(*types.Chan); !ok {
+		return nil
+	}
+	c := &localChan{v: v}
+	if len(call.Args) == 1 {
+		c.unbuffered = true
+	} else if tv := info.Types[call.Args[1]]; tv.Value != nil {
+		n, exact := constant.Int64Val(tv.Value)
+		c.unbuffered = exact && n == 0
+	}
+	return c
+}
+
+// builtinName returns the name of the built-in function called by call,
+// or "" if call does not call a built-in function.
+func builtinName(info *types.Info, call *ast.CallExpr) string {
+	if b, ok := typeutil.Callee(info, call).(*types.Builtin); ok {
+		return b.Name()
+	}
+	return ""
+}
+
+// selectOf returns the select statement that stmt, at the top of
+// stack, is a case of, if any.
+func selectOf(stack []ast.Node, stmt ast.Node) *ast.SelectStmt {
+	if len(stack) < 3 {
+		return nil
+	}
+	clause, ok := stack[len(stack)-1].(*ast.CommClause)
+	if !ok || clause.Comm != stmt {
+		return nil
+	}
+	sel, _ := stack[len(stack)-3].(*ast.SelectStmt)
+	return sel
+}
+
+// hasOtherCase reports whether sel has a case other than one
+// communicating on the channel c, or a default case.
+func hasOtherCase(sel *ast.SelectStmt, c *localChan, info *types.Info) bool {
+	for _, stmt := range sel.Body.List {
+		clause := stmt.(*ast.CommClause)
+		if clause.Comm == nil || !usesVar(info, clause.Comm, c.v) {
+			return true
+		}
+	}
+	return false
+}
+
+// usesVar reports whether n refers to v.
+func usesVar(info *types.Info, n ast.Node, v *types.Var) bool {
+	found := false
+	ast.Inspect(n, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && info.Uses[id] == v {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// checkRanges reports goroutines ranging over a channel that is never closed.
+func checkRanges(pass *analysis.Pass, c *localChan) {
+	if c.closed {
+		return
+	}
+	for _, op := range c.recvs {
+		if r, ok := op.node.(*ast.RangeStmt); ok && op.g != nil {
+			pass.ReportRangef(r, "goroutine ranging over channel %s leaks: %s is never closed", c.v.Name(), c.v.Name())
+		}
+	}
+}
+
+// checkSends reports goroutines that send on the unbuffered channel c
+// while the function that started them may not receive from it.
+func checkSends(pass *analysis.Pass, c *localChan, returns []*ast.ReturnStmt, goInLoop map[*ast.GoStmt]bool) {
+	// Give up if other goroutines receive from c, or if
+	// the function itself sends on it.
+	var recvs []chanOp
+	for _, op := range c.recvs {
+		if op.g != nil {
+			return
+		}
+		recvs = append(recvs, op)
+	}
+	for _, op := range c.sends {
+		if op.g == nil {
+			return
+		}
+	}
+
+	line := func(n ast.Node) int { return pass.Fset.Position(n.Pos()).Line }
+	reported := make(map[*ast.GoStmt]bool)
+	for _, send := range c.sends {
+		if send.sel != nil || reported[send.g] {
+			// A send in a select statement is not considered,
+			// since the statement may have other ways out.
+			continue
+		}
+		reported[send.g] = true
+		name := c.v.Name()
+
+		if len(recvs) == 0 {
+			pass.ReportRangef(send.node, "goroutine sending on unbuffered channel %s leaks: %s is never received from", name, name)
+			continue
+		}
+
+		// Timeout: every receive may be skipped by its select statement,
+		// which is not retried in a loop.
+		var sel *ast.SelectStmt
+		for _, recv := range recvs {
+			if recv.sel == nil || recv.inLoop || !hasOtherCase(recv.sel, c, pass.TypesInfo) {
+				sel = nil
+				break
+			}
+			if sel == nil {
+				sel = recv.sel
+			}
+		}
+		if sel != nil {
+			pass.ReportRangef(send.node, "goroutine sending on unbuffered channel %s may leak: the select statement at line %d receiving from %s may choose another case", name, line(sel), name)
+			continue
+		}
+
+		// N-cast: many senders, and a single receive.
+		if goInLoop[send.g] && !send.inLoop && len(recvs) == 1 {
+			_, isRange := recvs[0].node.(*ast.RangeStmt)
+			if !isRange && !recvs[0].inLoop {
+				pass.ReportRangef(send.node, "goroutines started in a loop send on unbuffered channel %s, which is only received from once: all but one may leak", name)
+				continue
+			}
+		}
+
+		// Early return: the function may return before its first receive.
+		var first ast.Node
+		for _, recv := range recvs {
+			if recv.node.Pos() > send.g.End() && (first == nil || recv.node.Pos() < first.Pos()) {
+				first = recv.node
+			}
+		}
+		if first == nil {
+			continue
+		}
+		for _, ret := range returns {
+			if ret.Pos() > send.g.End() && ret.End() <= first.Pos() {
+				pass.ReportRangef(send.node, "goroutine sending on unbuffered channel %s may leak: the function may return at line %d before receiving from %s", name, line(ret), name)
+				break
+			}
+		}
+	}
+}
+
+// checkDoubleSends reports sends on a channel parameter in an if
+// statement that does not return, followed by a send on the same
+// channel at the end of the function, as in
+//
+//	func f(ch chan T, err error) {
+//		if err != nil {
+//			ch <- nil // missing return
+//		}
+//		ch <- result
+//	}
+func checkDoubleSends(pass *analysis.Pass, typ *ast.FuncType, body *ast.BlockStmt) {
+	if len(body.List) < 2 {
+		return
+	}
+	last, ok := body.List[len(body.List)-1].(*ast.SendStmt)
+	if !ok {
+		return
+	}
+	v := paramChan(pass.TypesInfo, typ, last.Chan)
+	if v == nil {
+		return
+	}
+	for _, stmt := range body.List[:len(body.List)-1] {
+		ifStmt, ok := stmt.(*ast.IfStmt)
+		if !ok || ifStmt.Else != nil || len(ifStmt.Body.List) == 0 || terminates(pass.TypesInfo, ifStmt.Body) {
+			continue
+		}
+		for _, s := range ifStmt.Body.List {
+			if send, ok := s.(*ast.SendStmt); ok && paramChan(pass.TypesInfo, typ, send.Chan) == v {
+				pass.ReportRangef(send, "channel %s may be sent on twice: the send at line %d also runs after this one (missing return?)", v.Name(), pass.Fset.Position(last.Pos()).Line)
+			}
+		}
+	}
+}
+
+// paramChan returns the channel parameter of the function of type typ
+// that x refers to, if any.
+func paramChan(info *types.Info, typ *ast.FuncType, x ast.Expr) *types.Var {
+	id, ok := x.(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	v, ok := info.Uses[id].(*types.Var)
+	if !ok {
+		return nil
+	}
+	if _, ok := v.Type().Underlying().(*types.Chan); !ok {
+		return nil
+	}
+	for _, field := range typ.Params.List {
+		for _, name := range field.Names {
+			if info.Defs[name] == v {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+// terminates reports whether the last statement of block
+// leaves the enclosing block or function.
+func terminates(info *types.Info, block *ast.BlockStmt) bool {
+	switch s := block.List[len(block.List)-1].(type) {
+	case *ast.ReturnStmt, *ast.BranchStmt:
+		return true
+	case *ast.ExprStmt:
+		if call, ok := s.X.(*ast.CallExpr); ok {
+			if builtinName(info, call) == "panic" {
+				return true
+			}
+			if fn, ok := typeutil.Callee(info, call).(*types.Func); ok {
+				return isFunctionNamed(fn, "os", "Exit") || isFunctionNamed(fn, "runtime", "Goexit")
+			}
+		}
+	}
+	return false
+}
+
+// isFunctionNamed reports whether fn is the package-level function
+// pkgPath.name.
+func isFunctionNamed(fn *types.Func, pkgPath, name string) bool {
+	return fn.Pkg() != nil && fn.Pkg().Path() == pkgPath && fn.Name() == name &&
+		fn.Type().(*types.Signature).Recv() == nil
+}
+
+// exportStopFacts exports a stopFact for each method that starts
+// a goroutine which only returns once a channel field of the receiver
+// is closed by another method, as in
+//
+//	func (w *Worker) Start() {
+//		go func() {
+//			for {
+//				select {
+//				case <-w.ch:
+//				case <-w.done:
+//					return
+//				}
+//			}
+//		}()
+//	}
+//
+//	func (w *Worker) Stop() { close(w.done) }
+func exportStopFacts(pass *analysis.Pass, methods []*ast.FuncDecl) {
+	info := pass.TypesInfo
+
+	// closers maps each receiver type and field to the method closing it.
+	type key struct {
+		recv  *types.TypeName
+		field *types.Var
+	}
+	closers := make(map[key]*types.Func)
+	for _, decl := range methods {
+		fn, recv, recvVar := method(info, decl)
+		if fn == nil {
+			continue
+		}
+		ast.Inspect(decl.Body, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if ok && builtinName(info, call) == "close" && len(call.Args) == 1 {
+				if field := receiverField(info, call.Args[0], recvVar); field != nil {
+					closers[key{recv, field}] = fn
+				}
+			}
+			return true
+		})
+	}
+	if len(closers) == 0 {
+		return
+	}
+
+	for _, decl := range methods {
+		fn, recv, recvVar := method(info, decl)
+		if fn == nil {
+			continue
+		}
+		ast.Inspect(decl.Body, func(n ast.Node) bool {
+			g, ok := n.(*ast.GoStmt)
+			if !ok {
+				return true
+			}
+			lit, ok := g.Call.Fun.(*ast.FuncLit)
+			if !ok {
+				return false
+			}
+			for _, field := range exitFields(info, lit.Body, recvVar) {
+				if stop := closers[key{recv, field}]; stop != nil && stop != fn {
+					pass.ExportObjectFact(fn, &stopFact{Stop: stop.Name()})
+					return false
+				}
+			}
+			return false
+		})
+	}
+}
+
+// method returns the method declared by decl, the type name of its
+// receiver, and its receiver variable, if it has a named receiver.
+func method(info *types.Info, decl *ast.FuncDecl) (*types.Func, *types.TypeName, *types.Var) {
+	fn, ok := info.Defs[decl.Name].(*types.Func)
+	if !ok || len(decl.Recv.List) != 1 || len(decl.Recv.List[0].Names) != 1 {
+		return nil, nil, nil
+	}
+	recvVar, ok := info.Defs[decl.Recv.List[0].Names[0]].(*types.Var)
+	if !ok {
+		return nil, nil, nil
+	}
+	t := recvVar.Type()
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok {
+		return nil, nil, nil
+	}
+	return fn, named.Obj(), recvVar
+}
+
+// receiverField returns the field of recv selected by x, if x is of the
+// form recv.f.
+func receiverField(info *types.Info, x ast.Expr, recv *types.Var) *types.Var {
+	sel, ok := x.(*ast.SelectorExpr)
+	if !ok {
+		return nil
+	}
+	if id, ok := sel.X.(*ast.Ident); !ok || info.Uses[id] != recv {
+		return nil
+	}
+	field, _ := info.Uses[sel.Sel].(*types.Var)
+	if field == nil || !field.IsField() {
+		return nil
+	}
+	return field
+}
+
+// exitFields returns the fields of recv that the goroutine body waits on
+// in a select statement inside an infinite loop, in a case that returns.
+func exitFields(info *types.Info, body *ast.BlockStmt, recv *types.Var) []*types.Var {
+	var fields []*types.Var
+	ast.Inspect(body, func(n ast.Node) bool {
+		loop, ok := n.(*ast.ForStmt)
+		if !ok {
+			_, isLit := n.(*ast.FuncLit)
+			return !isLit
+		}
+		if loop.Cond != nil {
+			return true
+		}
+		for _, stmt := range loop.Body.List {
+			sel, ok := stmt.(*ast.SelectStmt)
+			if !ok {
+				continue
+			}
+			for _, stmt := range sel.Body.List {
+				clause := stmt.(*ast.CommClause)
+				if len(clause.Body) == 0 {
+					continue
+				}
+				if _, ok := clause.Body[len(clause.Body)-1].(*ast.ReturnStmt); !ok {
+					continue
+				}
+				var x ast.Expr
+				switch comm := clause.Comm.(type) {
+				case *ast.ExprStmt:
+					x = comm.X
+				case *ast.AssignStmt:
+					x = comm.Rhs[0]
+				}
+				if u, ok := x.(*ast.UnaryExpr); ok && u.Op == token.ARROW {
+					if field := receiverField(info, u.X, recv); field != nil {
+						fields = append(fields, field)
+					}
+				}
+			}
+		}
+		return true
+	})
+	return fields
+}
+
+// checkStops reports calls in body to methods with a stopFact on local
+// variables that are not passed elsewhere, without a call to the method
+// that stops the goroutine they start.
+func checkStops(pass *analysis.Pass, body *ast.BlockStmt) {
+	info := pass.TypesInfo
+
+	type start struct {
+		call *ast.CallExpr
+		fn   *types.Func
+		fact *stopFact
+	}
+	starts := make(map[*types.Var][]start)
+	called := make(map[*types.Var]map[string]bool)
+	escapes := make(map[*types.Var]bool)
+	inspectStack(body, func(n ast.Node, stack []ast.Node) bool {
+		id, ok := n.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		v, ok := info.Uses[id].(*types.Var)
+		if !ok || v.Pos() < body.Pos() || v.Pos() >= body.End() || v.IsField() {
+			return true
+		}
+		// v is a local variable of this function (or of a nested one).
+		sel, ok := stack[len(stack)-1].(*ast.SelectorExpr)
+		if !ok || sel.X != id {
+			escapes[v] = true
+			return true
+		}
+		fn, ok := info.Uses[sel.Sel].(*types.Func)
+		if !ok {
+			// Field selection.
+			return true
+		}
+		if len(stack) < 2 {
+			return true
+		}
+		call, ok := stack[len(stack)-2].(*ast.CallExpr)
+		if !ok || call.Fun != sel {
+			// Method value.
+			escapes[v] = true
+			return true
+		}
+		if called[v] == nil {
+			called[v] = make(map[string]bool)
+		}
+		called[v][fn.Name()] = true
+		var fact stopFact
+		if pass.ImportObjectFact(fn, &fact) {
+			starts[v] = append(starts[v], start{call, fn, &fact})
+		}
+		return true
+	})
+
+	for v, ss := range starts {
+		if escapes[v] {
+			continue
+		}
+		for _, s := range ss {
+			if !called[v][s.fact.Stop] {
+				pass.ReportRangef(s.call, "goroutine started by %s.%s leaks: %s.%s is never called", v.Name(), s.fn.Name(), v.Name(), s.fact.Stop)
+			}
+		}
+	}
+}
@@ -6,7 +6,11 @@ package main
 
 import (
 	"cmd/internal/objabi"
+	"cmd/vet/internal/goroutineleak"
+	"os"
+	"strings"
 
+	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/unitchecker"
 
 	"golang.org/x/tools/go/analysis/passes/appends"
@@ -46,7 +50,7 @@ import (
 func main() {
 	objabi.AddVersionFlag()
 
-	unitchecker.Main(
+	analyzers := []*analysis.Analyzer{
 		appends.Analyzer,
 		asmdecl.Analyzer,
 		assign.Analyzer,
@@ -79,5 +83,29 @@ func main() {
 		unreachable.Analyzer,
 		unsafeptr.Analyzer,
 		unusedresult.Analyzer,
-	)
+	}
+	// The goroutineleak check reports goroutines that may leak, which are
+	// not necessarily mistakes, so it is only registered when requested.
+	// It is also registered for -flags, so that go vet accepts the flag.
+	if hasFlag("goroutineleak") || hasFlag("flags") {
+		analyzers = append(analyzers, goroutineleak.Analyzer)
+	}
+
+	unitchecker.Main(analyzers...)
+}
+
+// hasFlag reports whether the command line sets the named flag,
+// or one of its -name.flag subflags.
+func hasFlag(name string) bool {
+	for _, arg := range os.Args[1:] {
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		arg = strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+		arg, _, _ = strings.Cut(arg, "=")
+		if arg == name || strings.HasPrefix(arg, name+".") {
+			return true
+		}
+	}
+	return false
 }
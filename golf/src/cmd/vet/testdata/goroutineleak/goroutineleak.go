@@ -0,0 +1,199 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains tests for the goroutineleak checker.
+
+package goroutineleak
+
+import "context"
+
+func Timeout(ctx context.Context) {
+	ch := make(chan int)
+	go func() {
+		ch <- 1 // ERROR "goroutine sending on unbuffered channel ch may leak: the select statement at line 16 receiving from ch may choose another case"
+	}()
+	select {
+	case <-ch:
+	case <-ctx.Done():
+	}
+}
+
+func BufferedTimeout(ctx context.Context) {
+	ch := make(chan int, 1)
+	go func() {
+		ch <- 1
+	}()
+	select {
+	case <-ch:
+	case <-ctx.Done():
+	}
+}
+
+func EarlyReturn(err error) {
+	ch := make(chan int)
+	go func() {
+		ch <- 1 // ERROR "goroutine sending on unbuffered channel ch may leak: the function may return at line 39 before receiving from ch"
+	}()
+	if err != nil {
+		return
+	}
+	<-ch
+}
+
+func ReturnAfterReceive(err error) int {
+	ch := make(chan int)
+	go func() {
+		ch <- 1
+	}()
+	v := <-ch
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+func ReturnReceive() int {
+	ch := make(chan int)
+	go func() {
+		ch <- 1
+	}()
+	return <-ch
+}
+
+func NCast(items []int) {
+	ch := make(chan int)
+	for range items {
+		go func() {
+			ch <- 1 // ERROR "goroutines started in a loop send on unbuffered channel ch, which is only received from once: all but one may leak"
+		}()
+	}
+	<-ch
+}
+
+func NCastReceiveAll(items []int) {
+	ch := make(chan int)
+	for range items {
+		go func() {
+			ch <- 1
+		}()
+	}
+	for range items {
+		<-ch
+	}
+}
+
+func NeverReceived() {
+	ch := make(chan int)
+	go func() {
+		ch <- 1 // ERROR "goroutine sending on unbuffered channel ch leaks: ch is never received from"
+	}()
+}
+
+func Escapes(f func(chan int)) {
+	ch := make(chan int)
+	go func() {
+		ch <- 1
+	}()
+	f(ch)
+}
+
+func SelectSend(ctx context.Context) {
+	ch := make(chan int)
+	go func() {
+		select {
+		case ch <- 1:
+		case <-ctx.Done():
+		}
+	}()
+	select {
+	case <-ch:
+	case <-ctx.Done():
+	}
+}
+
+func NoClose(items []int) {
+	ch := make(chan int)
+	go func() {
+		for range ch { // ERROR "goroutine ranging over channel ch leaks: ch is never closed"
+		}
+	}()
+	for _, item := range items {
+		ch <- item
+	}
+}
+
+func Close(items []int) {
+	ch := make(chan int)
+	go func() {
+		for range ch {
+		}
+	}()
+	for _, item := range items {
+		ch <- item
+	}
+	close(ch)
+}
+
+func DoubleSend(ch chan error, err error) {
+	if err != nil {
+		ch <- err // ERROR "channel ch may be sent on twice: the send at line 142 also runs after this one \(missing return\?\)"
+	}
+	ch <- nil
+}
+
+func SingleSend(ch chan error, err error) {
+	if err != nil {
+		ch <- err
+		return
+	}
+	ch <- nil
+}
+
+type Worker struct {
+	ch   chan int
+	done chan struct{}
+}
+
+func (w Worker) Start() {
+	go func() {
+		for {
+			select {
+			case <-w.ch:
+			case <-w.done:
+				return
+			}
+		}
+	}()
+}
+
+func (w Worker) Stop() {
+	close(w.done)
+}
+
+func (w Worker) Add(item int) {
+	w.ch <- item
+}
+
+func NotStopped(items []int) {
+	w := Worker{ch: make(chan int), done: make(chan struct{})}
+	w.Start() // ERROR "goroutine started by w.Start leaks: w.Stop is never called"
+	for _, item := range items {
+		w.Add(item)
+	}
+}
+
+func Stopped(items []int) {
+	w := Worker{ch: make(chan int), done: make(chan struct{})}
+	w.Start()
+	defer w.Stop()
+	for _, item := range items {
+		w.Add(item)
+	}
+}
+
+func Returned() Worker {
+	w := Worker{ch: make(chan int), done: make(chan struct{})}
+	w.Start()
+	return w
+}
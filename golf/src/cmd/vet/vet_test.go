@@ -73,6 +73,7 @@ func TestVet(t *testing.T) {
 		"copylock",
 		"deadcode",
 		"directive",
+		"goroutineleak",
 		"httpresponse",
 		"lostcancel",
 		"method",
@@ -97,7 +98,12 @@ func TestVet(t *testing.T) {
 				return
 			}
 
-			cmd := vetCmd(t, "-printfuncs=Warn,Warnf", pkg)
+			arg := "-printfuncs=Warn,Warnf"
+			if pkg == "goroutineleak" {
+				// The goroutineleak check only runs when requested.
+				arg = "-goroutineleak"
+			}
+			cmd := vetCmd(t, arg, pkg)
 
 			// The asm test assumes amd64.
 			if pkg == "asm" {
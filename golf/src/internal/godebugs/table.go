@@ -26,6 +26,8 @@ type Info struct {
 // (Otherwise the test in this package will fail.)
 var All = []Info{
 	{Name: "execerrdot", Package: "os/exec"},
+	{Name: "gcdetectdeadlocks", Package: "runtime"},
+	{Name: "gcgolfperf", Package: "runtime"},
 	{Name: "gocachehash", Package: "cmd/go"},
 	{Name: "gocachetest", Package: "cmd/go"},
 	{Name: "gocacheverify", Package: "cmd/go"},
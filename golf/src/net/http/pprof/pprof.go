@@ -32,7 +32,7 @@
 // Parameters can be passed via GET query params:
 //
 //   - debug=N (all profiles): response format: N = 0: binary (default), N > 0: plaintext
-//   - gc=N (heap profile): N > 0: run a garbage collection cycle before profiling
+//   - gc=N (deadlocks, heap profiles): N > 0: run a garbage collection cycle before profiling
 //   - seconds=N (allocs, block, goroutine, heap, mutex, threadcreate profiles): return a delta profile
 //   - seconds=N (cpu (profile), trace profiles): profile for the given duration
 //
@@ -56,6 +56,11 @@
 //
 //	go tool pprof http://localhost:6060/debug/pprof/mutex
 //
+// Or to list the goroutines found deadlocked by a garbage collection,
+// in a program run with GODEBUG=gcdetectdeadlocks=1 or 2:
+//
+//	curl http://localhost:6060/debug/pprof/deadlocks?debug=1&gc=1
+//
 // The package also exports a handler that serves execution trace data
 // for the "go tool trace" command. To collect a 5-second execution trace:
 //
@@ -254,7 +259,7 @@ func (name handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	gc, _ := strconv.Atoi(r.FormValue("gc"))
-	if name == "heap" && gc > 0 {
+	if (name == "heap" || name == "deadlocks") && gc > 0 {
 		runtime.GC()
 	}
 	debug, _ := strconv.Atoi(r.FormValue("debug"))
@@ -359,6 +364,7 @@ var profileDescriptions = map[string]string{
 	"allocs":       "A sampling of all past memory allocations",
 	"block":        "Stack traces that led to blocking on synchronization primitives",
 	"cmdline":      "The command line invocation of the current program",
+	"deadlocks":    "Stack traces of goroutines found deadlocked by the garbage collector, under GODEBUG=gcdetectdeadlocks. You can specify the gc GET parameter to run GC, and thereby deadlock detection, before taking the profile.",
 	"goroutine":    "Stack traces of all current goroutines. Use debug=2 as a query parameter to export in the same format as an unrecovered panic.",
 	"heap":         "A sampling of memory allocations of live objects. You can specify the gc GET parameter to run GC before taking the heap sample.",
 	"mutex":        "Stack traces of holders of contended mutexes",
@@ -13,7 +13,9 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"runtime/pprof"
 	"strings"
@@ -46,6 +48,8 @@ func TestHandlers(t *testing.T) {
 		{"/debug/pprof/<script>scripty<script>", Index, http.StatusNotFound, "text/plain; charset=utf-8", "", []byte("Unknown profile\n")},
 		{"/debug/pprof/heap", Index, http.StatusOK, "application/octet-stream", `attachment; filename="heap"`, nil},
 		{"/debug/pprof/heap?debug=1", Index, http.StatusOK, "text/plain; charset=utf-8", "", nil},
+		{"/debug/pprof/deadlocks?gc=1", Index, http.StatusOK, "application/octet-stream", `attachment; filename="deadlocks"`, nil},
+		{"/debug/pprof/deadlocks?debug=1", Index, http.StatusOK, "text/plain; charset=utf-8", "", []byte("deadlocks profile: total 0\n")},
 		{"/debug/pprof/cmdline", Cmdline, http.StatusOK, "text/plain; charset=utf-8", "", nil},
 		{"/debug/pprof/profile?seconds=1", Profile, http.StatusOK, "application/octet-stream", `attachment; filename="profile"`, nil},
 		{"/debug/pprof/symbol", Symbol, http.StatusOK, "text/plain; charset=utf-8", "", nil},
@@ -95,6 +99,84 @@ func TestHandlers(t *testing.T) {
 
 var Sink uint32
 
+func deadlockedReceiver(started chan<- struct{}) {
+	close(started)
+	<-make(chan int)
+}
+
+func TestDeadlocksProfile(t *testing.T) {
+	if os.Getenv("GO_PPROF_TEST_DEADLOCKS") == "" {
+		// Deadlocked goroutines are only kept around for the profile
+		// under GODEBUG=gcdetectdeadlocks=2.
+		testenv.MustHaveExec(t)
+		exe, err := os.Executable()
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmd := testenv.Command(t, exe, "-test.run=^TestDeadlocksProfile$", "-test.v")
+		cmd.Env = append(os.Environ(), "GODEBUG=gcdetectdeadlocks=2", "GO_PPROF_TEST_DEADLOCKS=1")
+		out, err := cmd.CombinedOutput()
+		t.Logf("%s", out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	get := func(path string) []byte {
+		req := httptest.NewRequest("GET", "http://example.com"+path, nil)
+		w := httptest.NewRecorder()
+		Index(w, req)
+		resp := w.Result()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: status code %d", path, resp.StatusCode)
+		}
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return body
+	}
+
+	started := make(chan struct{})
+	go deadlockedReceiver(started)
+	<-started
+	// gc=1 runs the garbage collection that finds the goroutine deadlocked.
+	var body []byte
+	for i := 0; ; i++ {
+		body = get("/debug/pprof/deadlocks?debug=1&gc=1")
+		if !bytes.HasPrefix(body, []byte("deadlocks profile: total 0\n")) {
+			break
+		}
+		if i == 100 {
+			t.Fatal("no deadlocked goroutine found")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Like in tracebacks, only frames of calls that were not inlined
+	// have a PC offset.
+	want := `^deadlocks profile: total 1
+
+partial deadlock! goroutine \d+: net/http/pprof\.\S+ \[chan receive\] Stack size: \d+ bytes
+runtime\.gopark\(...\)
+\t.+/proc.go:\d+ \+0x[0-9a-f]+
+(?:.+\n\t.+\n)*net/http/pprof\.deadlockedReceiver\(...\)
+\t.+/pprof_test.go:\d+(?: \+0x[0-9a-f]+)?
+$`
+	if !regexp.MustCompile(want).Match(body) {
+		t.Errorf("got deadlocks profile:\n%s\nwant matching:\n%s", body, want)
+	}
+
+	p, err := profile.Parse(bytes.NewReader(get("/debug/pprof/deadlocks?gc=1")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Sample) != 1 || !seen(p, "deadlockedReceiver") {
+		t.Errorf("deadlocks profile does not have deadlockedReceiver as only sample:\n%v", p)
+	}
+}
+
 func mutexHog1(mu1, mu2 *sync.Mutex, start time.Time, dt time.Duration) {
 	atomic.AddUint32(&Sink, 1)
 	for time.Since(start) < dt {
@@ -0,0 +1,200 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Support for inspecting blocked and deadlocked goroutines
+// from package runtime/debug.
+
+package runtime
+
+import _ "unsafe" // for go:linkname
+
+// blockedGoroutineRecord describes a blocked goroutine.
+// It must be kept in sync with runtime/debug.goroutineRecord
+// and runtime/pprof.deadlockRecord.
+type blockedGoroutineRecord struct {
+	id         uint64
+	waitReason string
+	waitTime   int64 // -1 if not yet observed blocked by a GC cycle
+	deadlocked bool
+	stack      StackRecord
+	startPC    uintptr // PC of the goroutine's function
+	stackSize  uintptr
+}
+
+// runtime_debug_readBlockedGoroutines fills p with the goroutines that
+// have been blocked for at least threshold nanoseconds, on a channel
+// operation, a select statement or a package sync primitive, and with
+// all goroutines found deadlocked by the GC but not yet reclaimed.
+// If deadlockedOnly is set, only the latter are included.
+//
+// Blocking time is measured from the first GC cycle that found the
+// goroutine blocked, so goroutines blocked since before the last GC
+// cycle may not be included, unless threshold is negative.
+//
+// If len(p) is too small, p is left untouched and ok is false.
+// In either case, n is the number of matching goroutines.
+//
+//go:linkname runtime_debug_readBlockedGoroutines runtime/debug.readBlockedGoroutines
+func runtime_debug_readBlockedGoroutines(p []blockedGoroutineRecord, threshold int64, deadlockedOnly bool) (n int, ok bool) {
+	stw := stopTheWorld(stwGoroutineProfile)
+
+	// World is stopped, no locking required.
+	now := nanotime()
+	waitTime := func(gp *g) int64 {
+		if gp.waitsince == 0 {
+			return -1
+		}
+		return now - gp.waitsince
+	}
+	isBlocked := func(gp *g) bool {
+		if isSystemGoroutine(gp, false) {
+			return false
+		}
+		switch readgstatus(gp) {
+		case _Gunreachable, _Gdeadlocked:
+			return true
+		case _Gwaiting:
+			return !deadlockedOnly &&
+				!unblockingWaitReason(gp.waitreason) &&
+				waitTime(gp) >= threshold
+		}
+		return false
+	}
+
+	forEachGRace(func(gp *g) {
+		if isBlocked(gp) {
+			n++
+		}
+	})
+
+	if n <= len(p) {
+		ok = true
+		r := p
+		forEachGRace(func(gp *g) {
+			if !isBlocked(gp) || len(r) == 0 {
+				return
+			}
+			r[0] = blockedGoroutineRecord{
+				id:         gp.goid,
+				waitReason: gp.waitreason.String(),
+				waitTime:   waitTime(gp),
+				deadlocked: readgstatus(gp) != _Gwaiting,
+				startPC:    gp.startpc,
+				stackSize:  gp.stack.hi - gp.stack.lo,
+			}
+			// See goroutineProfileWithLabelsSync.
+			systemstack(func() { saveg(^uintptr(0), ^uintptr(0), gp, &r[0].stack) })
+			r = r[1:]
+		})
+	}
+
+	startTheWorld(stw)
+	return n, ok
+}
+
+// runtime_pprof_readDeadlockedGoroutines is like
+// runtime_debug_readBlockedGoroutines with deadlockedOnly set,
+// for the deadlocks profile of package runtime/pprof.
+//
+//go:linkname runtime_pprof_readDeadlockedGoroutines runtime/pprof.runtime_readDeadlockedGoroutines
+func runtime_pprof_readDeadlockedGoroutines(p []blockedGoroutineRecord) (n int, ok bool) {
+	return runtime_debug_readBlockedGoroutines(p, 0, true)
+}
+
+// runtime_debug_reclaimDeadlockedGoroutines reclaims the goroutine with
+// ID goid, or all goroutines if all is set, among those marked as
+// deadlocked by the GC, as if they had been found deadlocked under
+// GODEBUG=gcdetectdeadlocks=1. It returns the number of reclaimed
+// goroutines.
+//
+//go:linkname runtime_debug_reclaimDeadlockedGoroutines runtime/debug.reclaimDeadlockedGoroutines
+func runtime_debug_reclaimDeadlockedGoroutines(goid uint64, all bool) (n int) {
+	if debug.gcdetectdeadlocks != 2 {
+		// No goroutine is ever marked as deadlocked.
+		return 0
+	}
+
+	// Deadlocked goroutines must not be reclaimed while a GC cycle
+	// is marking, since their stacks may be queued for scanning.
+	stw := stopTheWorldGC(stwReclaimDeadlocked)
+	if gcphase != _GCoff {
+		throw("reclaimDeadlockedGoroutines: GC in progress")
+	}
+
+	// World is stopped, no locking required.
+	systemstack(func() {
+		forEachGRace(func(gp *g) {
+			status := readgstatus(gp)
+			if status != _Gunreachable && status != _Gdeadlocked || !all && gp.goid != goid {
+				return
+			}
+			if status == _Gdeadlocked {
+				casgstatus(gp, _Gdeadlocked, _Gunreachable)
+			}
+			gcGoexit(gp)
+			n++
+		})
+	})
+
+	startTheWorldGC(stw)
+	return n
+}
+
+// testing_runtime_enableLeakCheck switches partial deadlock detection to
+// monitor mode (GODEBUG=gcdetectdeadlocks=2), without reporting, so that
+// package testing can attribute deadlocked goroutines to tests under
+// go test -leakcheck. Detection may be enabled at any time, as long as
+// no GC cycle is in progress, but never disabled, since pointers masked
+// from the GC would then be mistaken for dead ones.
+//
+//go:linkname testing_runtime_enableLeakCheck testing.runtime_enableLeakCheck
+func testing_runtime_enableLeakCheck() {
+	stw := stopTheWorldGC(stwEnableLeakCheck)
+	debug.gcdetectdeadlocks = 2
+	debug.gcgolfperf = 1
+	startTheWorldGC(stw)
+}
+
+// testing_runtime_setAncestryID sets the ancestry ID of the calling
+// goroutine. Goroutines it creates from now on inherit the ID, and
+// pass it on to the goroutines they create in turn.
+//
+//go:linkname testing_runtime_setAncestryID testing.runtime_setAncestryID
+func testing_runtime_setAncestryID(id uint64) {
+	getg().ancestryID = id
+}
+
+// testing_runtime_readDeadlockedGoroutineIDs fills p with the IDs of the
+// goroutines found deadlocked by the GC, but not yet reclaimed, that
+// descend from a goroutine with the given ancestry ID. It follows the
+// conventions of runtime_debug_readBlockedGoroutines.
+//
+//go:linkname testing_runtime_readDeadlockedGoroutineIDs testing.runtime_readDeadlockedGoroutineIDs
+func testing_runtime_readDeadlockedGoroutineIDs(p []uint64, ancestryID uint64) (n int, ok bool) {
+	stw := stopTheWorld(stwGoroutineProfile)
+
+	// World is stopped, no locking required.
+	isLeaked := func(gp *g) bool {
+		status := readgstatus(gp)
+		return (status == _Gunreachable || status == _Gdeadlocked) && gp.ancestryID == ancestryID
+	}
+	forEachGRace(func(gp *g) {
+		if isLeaked(gp) {
+			n++
+		}
+	})
+	if n <= len(p) {
+		ok = true
+		r := p
+		forEachGRace(func(gp *g) {
+			if isLeaked(gp) && len(r) > 0 {
+				r[0] = gp.goid
+				r = r[1:]
+			}
+		})
+	}
+
+	startTheWorld(stw)
+	return n, ok
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug
+
+import (
+	"runtime"
+	"time"
+)
+
+// BlockedGoroutine describes a goroutine that is blocked on a channel
+// operation, a select statement or a package sync primitive.
+type BlockedGoroutine struct {
+	ID         uint64 // goroutine ID, as reported in tracebacks
+	WaitReason string // e.g., "chan receive" or "sync.Mutex.Lock"
+
+	// WaitTime is a lower bound on how long the goroutine has been
+	// blocked. It is measured from the first garbage collection that
+	// found the goroutine blocked, and is negative if no garbage
+	// collection has happened since the goroutine blocked.
+	WaitTime time.Duration
+
+	// Deadlocked reports whether the garbage collector has proven
+	// that the goroutine can never be unblocked.
+	// This requires GODEBUG=gcdetectdeadlocks=2.
+	Deadlocked bool
+
+	// Stack holds the program counters of the goroutine stack,
+	// suitable for runtime.CallersFrames.
+	Stack []uintptr
+}
+
+// goroutineRecord must be kept in sync with runtime.blockedGoroutineRecord.
+type goroutineRecord struct {
+	id         uint64
+	waitReason string
+	waitTime   int64
+	deadlocked bool
+	stack      runtime.StackRecord
+	startPC    uintptr
+	stackSize  uintptr
+}
+
+// BlockedGoroutines returns the goroutines that have been blocked
+// for at least threshold, together with every goroutine found deadlocked
+// by the garbage collector, regardless of how long it has been blocked.
+// Goroutines are only known to be blocked once a garbage collection
+// observes them, so goroutines that blocked since the last garbage
+// collection are only included if threshold is negative.
+//
+// Goroutines that are blocked for a long time without being deadlocked
+// are suspected leaks: BlockedGoroutines is meant for finding leaks that
+// escape deadlock detection, e.g., because the channel they are blocked
+// on remains reachable from a global variable.
+func BlockedGoroutines(threshold time.Duration) []BlockedGoroutine {
+	return blockedGoroutines(int64(threshold), false)
+}
+
+// DeadlockedGoroutines returns the goroutines that the garbage collector
+// has proven deadlocked, i.e., blocked forever because no other goroutine
+// can unblock them. Under GODEBUG=gcdetectdeadlocks=2, such goroutines
+// are kept alive, along with the memory their stacks refer to, until
+// they are reclaimed with [ReclaimDeadlockedGoroutine] or
+// [ReclaimDeadlockedGoroutines]. Under GODEBUG=gcdetectdeadlocks=1,
+// they are reclaimed by the garbage collection following the one that
+// found them.
+func DeadlockedGoroutines() []BlockedGoroutine {
+	return blockedGoroutines(0, true)
+}
+
+// ReclaimDeadlockedGoroutine reclaims the deadlocked goroutine with the
+// given ID, as reported by [DeadlockedGoroutines], and the memory only its
+// stack refers to, as GODEBUG=gcdetectdeadlocks=1 would have done.
+// Deferred calls of the goroutine are not run.
+// It reports whether such a goroutine was found.
+//
+// ReclaimDeadlockedGoroutine waits for any garbage collection in progress
+// to finish, and stops the world while reclaiming.
+func ReclaimDeadlockedGoroutine(id uint64) bool {
+	return reclaimDeadlockedGoroutines(id, false) > 0
+}
+
+// ReclaimDeadlockedGoroutines reclaims all deadlocked goroutines, like
+// [ReclaimDeadlockedGoroutine], and returns how many were reclaimed.
+func ReclaimDeadlockedGoroutines() int {
+	return reclaimDeadlockedGoroutines(0, true)
+}
+
+func blockedGoroutines(threshold int64, deadlockedOnly bool) []BlockedGoroutine {
+	var p []goroutineRecord
+	n, ok := readBlockedGoroutines(nil, threshold, deadlockedOnly)
+	for !ok {
+		// Allow room for a few more goroutines to block in the meantime.
+		p = make([]goroutineRecord, n+10)
+		n, ok = readBlockedGoroutines(p, threshold, deadlockedOnly)
+	}
+	gs := make([]BlockedGoroutine, n)
+	for i, r := range p[:n] {
+		gs[i] = BlockedGoroutine{
+			ID:         r.id,
+			WaitReason: r.waitReason,
+			WaitTime:   time.Duration(r.waitTime),
+			Deadlocked: r.deadlocked,
+			Stack:      r.stack.Stack(),
+		}
+	}
+	return gs
+}
@@ -0,0 +1,237 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug_test
+
+import (
+	"internal/testenv"
+	"os"
+	"runtime"
+	. "runtime/debug"
+	"testing"
+	"time"
+)
+
+// inGODEBUGSubprocess reports whether the running test is executing in a
+// subprocess under the given GODEBUG setting. If not, it reruns the test
+// in such a subprocess and reports its failure.
+func inGODEBUGSubprocess(t *testing.T, godebug string) bool {
+	if os.Getenv("GO_RUNTIME_DEBUG_TEST_GODEBUG") == godebug {
+		return true
+	}
+	testenv.MustHaveExec(t)
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := testenv.Command(t, exe, "-test.run=^"+t.Name()+"$", "-test.v")
+	cmd.Env = append(os.Environ(), "GODEBUG="+godebug, "GO_RUNTIME_DEBUG_TEST_GODEBUG="+godebug)
+	out, err := cmd.CombinedOutput()
+	t.Logf("GODEBUG=%s %v:\n%s", godebug, cmd, out)
+	if err != nil {
+		t.Fatalf("subprocess failed: %v", err)
+	}
+	return false
+}
+
+// blockedReceiver blocks receiving from ch, after closing started.
+func blockedReceiver(started chan<- struct{}, ch <-chan struct{}) {
+	close(started)
+	<-ch
+}
+
+// findBlocked returns the goroutine of gs running fn, if any.
+func findBlocked(gs []BlockedGoroutine, fn string) (BlockedGoroutine, bool) {
+	for _, g := range gs {
+		frames := runtime.CallersFrames(g.Stack)
+		for {
+			frame, more := frames.Next()
+			if frame.Function == fn {
+				return g, true
+			}
+			if !more {
+				break
+			}
+		}
+	}
+	return BlockedGoroutine{}, false
+}
+
+func TestBlockedGoroutines(t *testing.T) {
+	const fn = "runtime/debug_test.blockedReceiver"
+
+	start := time.Now()
+	started, ch := make(chan struct{}), make(chan struct{})
+	go blockedReceiver(started, ch)
+	defer close(ch)
+	<-started
+	for {
+		// Wait for the goroutine to block.
+		if _, ok := findBlocked(BlockedGoroutines(-1), fn); ok {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Blocking time is only measured from the first GC that finds
+	// the goroutine blocked.
+	runtime.GC()
+	const wait = 20 * time.Millisecond
+	time.Sleep(wait)
+
+	if g, ok := findBlocked(BlockedGoroutines(time.Hour), fn); ok {
+		t.Errorf("BlockedGoroutines(time.Hour) includes goroutine %d blocked for %v", g.ID, g.WaitTime)
+	}
+	g, ok := findBlocked(BlockedGoroutines(wait), fn)
+	elapsed := time.Since(start)
+	if !ok {
+		t.Fatalf("BlockedGoroutines(%v) does not include goroutine blocked for %v", wait, elapsed)
+	}
+	if g.WaitTime < wait || g.WaitTime > elapsed {
+		t.Errorf("WaitTime = %v, want between %v and %v", g.WaitTime, wait, elapsed)
+	}
+	if g.WaitReason != "chan receive" {
+		t.Errorf("WaitReason = %q, want %q", g.WaitReason, "chan receive")
+	}
+	if g.Deadlocked {
+		t.Errorf("goroutine %d blocked on a reachable channel is reported as deadlocked", g.ID)
+	}
+}
+
+func TestBlockedGoroutinesDeadlocked(t *testing.T) {
+	if !inGODEBUGSubprocess(t, "gcdetectdeadlocks=2") {
+		return
+	}
+	const fn = "runtime/debug_test.blockedReceiver"
+
+	started := make(chan struct{})
+	go blockedReceiver(started, make(chan struct{}))
+	<-started
+	for i := 0; ; i++ {
+		// Deadlocked goroutines are included regardless of threshold.
+		runtime.GC()
+		g, ok := findBlocked(BlockedGoroutines(time.Hour), fn)
+		if ok {
+			if !g.Deadlocked {
+				t.Errorf("goroutine %d blocked on an unreachable channel is not reported as deadlocked", g.ID)
+			}
+			break
+		}
+		if i == 100 {
+			t.Fatal("deadlocked goroutine not found")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	ReclaimDeadlockedGoroutines()
+}
+
+// startDeadlocked starts n goroutines blocked forever in blockedReceiver,
+// and returns their IDs once the GC has found them deadlocked.
+func startDeadlocked(t *testing.T, n int) []uint64 {
+	const fn = "runtime/debug_test.blockedReceiver"
+
+	for i := 0; i < n; i++ {
+		started := make(chan struct{})
+		go blockedReceiver(started, make(chan struct{}))
+		<-started
+	}
+	for i := 0; ; i++ {
+		runtime.GC()
+		var ids []uint64
+		for _, g := range DeadlockedGoroutines() {
+			if _, ok := findBlocked([]BlockedGoroutine{g}, fn); ok {
+				if !g.Deadlocked {
+					t.Errorf("DeadlockedGoroutines includes goroutine %d, not deadlocked", g.ID)
+				}
+				ids = append(ids, g.ID)
+			}
+		}
+		if len(ids) == n {
+			return ids
+		}
+		if i == 100 {
+			t.Fatalf("found %d deadlocked goroutines, want %d", len(ids), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDeadlockedGoroutines(t *testing.T) {
+	if !inGODEBUGSubprocess(t, "gcdetectdeadlocks=2") {
+		return
+	}
+
+	ids := startDeadlocked(t, 3)
+	// Deadlocked goroutines survive further GC cycles.
+	runtime.GC()
+	if n := len(DeadlockedGoroutines()); n != len(ids) {
+		t.Fatalf("after GC, %d deadlocked goroutines, want %d", n, len(ids))
+	}
+
+	if !ReclaimDeadlockedGoroutine(ids[0]) {
+		t.Fatalf("ReclaimDeadlockedGoroutine(%d) = false, want true", ids[0])
+	}
+	if ReclaimDeadlockedGoroutine(ids[0]) {
+		t.Errorf("ReclaimDeadlockedGoroutine(%d) = true for a reclaimed goroutine", ids[0])
+	}
+	gs := DeadlockedGoroutines()
+	if len(gs) != len(ids)-1 {
+		t.Fatalf("after reclaiming one, %d deadlocked goroutines, want %d", len(gs), len(ids)-1)
+	}
+	for _, g := range gs {
+		if g.ID == ids[0] {
+			t.Errorf("reclaimed goroutine %d is still listed as deadlocked", g.ID)
+		}
+	}
+
+	if n := ReclaimDeadlockedGoroutines(); n != len(ids)-1 {
+		t.Errorf("ReclaimDeadlockedGoroutines() = %d, want %d", n, len(ids)-1)
+	}
+	if gs := DeadlockedGoroutines(); len(gs) != 0 {
+		t.Errorf("after reclaiming all, %d deadlocked goroutines, want 0", len(gs))
+	}
+	if n := ReclaimDeadlockedGoroutines(); n != 0 {
+		t.Errorf("ReclaimDeadlockedGoroutines() = %d with no deadlocked goroutines, want 0", n)
+	}
+}
+
+func TestReclaimDeadlockedGoroutinesLockedThread(t *testing.T) {
+	if !inGODEBUGSubprocess(t, "gcdetectdeadlocks=2") {
+		return
+	}
+
+	ids := startDeadlocked(t, 2)
+	done := make(chan int)
+	go func() {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		n := 0
+		if ReclaimDeadlockedGoroutine(ids[0]) {
+			n++
+		}
+		done <- n + ReclaimDeadlockedGoroutines()
+	}()
+	if n := <-done; n != len(ids) {
+		t.Errorf("reclaimed %d goroutines from a locked thread, want %d", n, len(ids))
+	}
+}
+
+func TestReclaimDeadlockedGoroutinesDisabled(t *testing.T) {
+	if !inGODEBUGSubprocess(t, "gcdetectdeadlocks=1") {
+		return
+	}
+
+	// Goroutines found deadlocked are reclaimed by the GC itself.
+	started := make(chan struct{})
+	go blockedReceiver(started, make(chan struct{}))
+	<-started
+	runtime.GC()
+	runtime.GC()
+	if gs := DeadlockedGoroutines(); len(gs) != 0 {
+		t.Errorf("%d deadlocked goroutines left under gcdetectdeadlocks=1", len(gs))
+	}
+	if n := ReclaimDeadlockedGoroutines(); n != 0 {
+		t.Errorf("ReclaimDeadlockedGoroutines() = %d under gcdetectdeadlocks=1, want 0", n)
+	}
+}
@@ -16,3 +16,5 @@ func setGCPercent(int32) int32
 func setPanicOnFault(bool) bool
 func setMaxThreads(int) int
 func setMemoryLimit(int64) int64
+func readBlockedGoroutines(p []goroutineRecord, threshold int64, deadlockedOnly bool) (n int, ok bool)
+func reclaimDeadlockedGoroutines(id uint64, all bool) int
@@ -8,6 +8,11 @@
 
 // The format of the dumped file is described at
 // https://golang.org/s/go15heapdump.
+//
+// With GODEBUG=gcdetectdeadlocks set, the dump also contains a
+// tagGoroutineWait record after each goroutine record (see
+// dumpgoroutinewait), and pointers masked from the GC are unmasked
+// in dumped objects (see dumpobj).
 
 package runtime
 
@@ -63,6 +68,7 @@ const (
 	tagPanic           = 15
 	tagMemProf         = 16
 	tagAllocSample     = 17
+	tagGoroutineWait   = 18
 )
 
 var dumpfd uintptr // fd to write the dump to.
@@ -213,10 +219,29 @@ func dumptype(t *_type) {
 func dumpobj(obj unsafe.Pointer, size uintptr, bv bitvector) {
 	dumpint(tagObject)
 	dumpint(uint64(uintptr(obj)))
-	dumpmemrange(obj, size)
+	if debug.gcdetectdeadlocks != 0 {
+		dumpunmaskedrange(obj, size, bv)
+	} else {
+		dumpmemrange(obj, size)
+	}
 	dumpfields(bv)
 }
 
+// dumpunmaskedrange is like dumpmemrange, but unmasks the pointers
+// described by bv, such as the entries of allgs or the elem field of
+// sudogs queued on semaphores, which are hidden from the GC with gcMask.
+func dumpunmaskedrange(obj unsafe.Pointer, size uintptr, bv bitvector) {
+	dumpint(uint64(size))
+	for i := uintptr(0); i < size/goarch.PtrSize; i++ {
+		v := *(*uintptr)(add(obj, i*goarch.PtrSize))
+		if i < uintptr(bv.n) && bv.ptrbit(i) == 1 {
+			v = uintptr(gcUnmask(unsafe.Pointer(v)))
+		}
+		dwrite(unsafe.Pointer(&v), goarch.PtrSize)
+	}
+	dwrite(add(obj, size&^(goarch.PtrSize-1)), size&(goarch.PtrSize-1))
+}
+
 func dumpotherroot(description string, to unsafe.Pointer) {
 	dumpint(tagOtherRoot)
 	dumpstr(description)
@@ -403,6 +428,29 @@ func dumpgoroutine(gp *g) {
 		dumpint(0) // was p->defer, no longer recorded
 		dumpint(uint64(uintptr(unsafe.Pointer(p.link))))
 	}
+
+	if debug.gcdetectdeadlocks != 0 {
+		dumpgoroutinewait(gp)
+	}
+}
+
+// dumpgoroutinewait records what gp is blocked on: the semaphore or
+// notify list it waits on, if any, followed by the channels of the
+// operations it is blocked on. The goroutine status, which may be
+// _Gunreachable or _Gdeadlocked, is already part of the goroutine record.
+func dumpgoroutinewait(gp *g) {
+	dumpint(tagGoroutineWait)
+	dumpint(uint64(uintptr(unsafe.Pointer(gp))))
+	dumpint(uint64(uintptr(gcUnmask(gp.waiting_sema))))
+	dumpint(uint64(uintptr(gcUnmask(gp.waiting_notifier))))
+	var n uint64
+	for sg := gp.waiting; sg != nil; sg = sg.waitlink {
+		n++
+	}
+	dumpint(n)
+	for sg := gp.waiting; sg != nil; sg = sg.waitlink {
+		dumpint(uint64(uintptr(gcUnmask(unsafe.Pointer(sg.c)))))
+	}
 }
 
 func dumpgs() {
@@ -419,7 +467,9 @@ func dumpgs() {
 			// ok
 		case _Grunnable,
 			_Gsyscall,
-			_Gwaiting:
+			_Gwaiting,
+			_Gunreachable,
+			_Gdeadlocked:
 			dumpgoroutine(gp)
 		}
 	})
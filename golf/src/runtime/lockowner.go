@@ -0,0 +1,282 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Lock ownership tracking for partial deadlock reports.
+//
+// With GODEBUG=gcddlockowners=1 or gcddorphanlocks=1, package sync informs the runtime
+// whenever a sync.Mutex, or the write half of a sync.RWMutex, is
+// acquired or released. The runtime remembers the goroutine that last
+// acquired each lock, together with the stack at the acquisition point,
+// so that a goroutine found deadlocked on the lock can be reported
+// alongside the goroutine holding it.
+//
+// With GODEBUG=gcddorphanlocks=1, the runtime additionally reports
+// goroutines blocked on a lock whose owner has exited without releasing
+// it (see detectOrphanedLocks).
+//
+// Records are keyed by the address of the semaphore on which waiters
+// for the lock park, i.e., the (unmasked) value of gp.waiting_sema.
+// Records live outside the heap and refer to locks and goroutines only
+// via uintptr, so tracking never makes a lock, or anything its owner is
+// blocked on, reachable to the GC.
+
+package runtime
+
+import (
+	"internal/abi"
+	"internal/cpu"
+	"runtime/internal/sys"
+	"unsafe"
+)
+
+// Maximum number of frames recorded at lock acquisition.
+const lockOwnerDepth = 32
+
+// lockOwner describes the goroutine that last acquired a lock.
+type lockOwner struct {
+	gp   guintptr // owning goroutine; may have exited and been reused since
+	goid uint64   // goroutine id of the owner at acquisition time
+	npcs int
+	pcs  [lockOwnerDepth]uintptr // pcs from the stack of the owner at acquisition time
+}
+
+// A lockOwnerRecord associates a lockOwner with a lock address.
+type lockOwnerRecord struct {
+	_    sys.NotInHeap
+	addr uintptr
+	next *lockOwnerRecord
+	lockOwner
+}
+
+type lockOwnerRoot struct {
+	lock mutex
+	head *lockOwnerRecord
+}
+
+// Prime to not correlate with any user patterns.
+const lockOwnerTabSize = 251
+
+var lockOwners struct {
+	tab [lockOwnerTabSize]struct {
+		root lockOwnerRoot
+		pad  [cpu.CacheLinePadSize - unsafe.Sizeof(lockOwnerRoot{})]byte
+	}
+
+	// Released records are kept for reuse.
+	freeLock mutex
+	free     *lockOwnerRecord
+}
+
+func lockOwnerRootFor(addr uintptr) *lockOwnerRoot {
+	return &lockOwners.tab[(addr>>3)%lockOwnerTabSize].root
+}
+
+// sync_runtime_lockOwnerTracking reports whether package sync should report
+// lock acquisitions and releases. It is consulted once, when package
+// sync is initialized.
+//
+//go:linkname sync_runtime_lockOwnerTracking sync.runtime_lockOwnerTracking
+func sync_runtime_lockOwnerTracking() bool {
+	return debug.gcddlockowners != 0 || debug.gcddorphanlocks != 0
+}
+
+// sync_runtime_lockAcquired records the calling goroutine as the owner
+// of the lock whose waiters park on addr.
+//
+//go:linkname sync_runtime_lockAcquired sync.runtime_lockAcquired
+func sync_runtime_lockAcquired(addr *uint32, skipframes int) {
+	gp := getg()
+
+	var owner lockOwner
+	owner.gp.set(gp)
+	owner.goid = gp.goid
+	owner.npcs = callers(1+skipframes, owner.pcs[:])
+
+	a := uintptr(unsafe.Pointer(addr))
+	root := lockOwnerRootFor(a)
+
+	// Allocate ahead of time, because root.lock is a leaf lock.
+	r := newLockOwnerRecord()
+	lock(&root.lock)
+	for s := root.head; s != nil; s = s.next {
+		if s.addr == a {
+			s.lockOwner = owner
+			unlock(&root.lock)
+			freeLockOwnerRecord(r)
+			return
+		}
+	}
+	r.addr = a
+	r.lockOwner = owner
+	r.next = root.head
+	root.head = r
+	unlock(&root.lock)
+}
+
+// sync_runtime_lockReleased forgets the owner of the lock whose waiters
+// park on addr.
+//
+// A lock may be released by another goroutine than the one that acquired
+// it, so the record is dropped whoever the caller is. Package sync calls
+// this before the lock is released, so the record cannot belong to the
+// next owner yet.
+//
+//go:linkname sync_runtime_lockReleased sync.runtime_lockReleased
+func sync_runtime_lockReleased(addr *uint32) {
+	a := uintptr(unsafe.Pointer(addr))
+	root := lockOwnerRootFor(a)
+
+	lock(&root.lock)
+	for ps, s := &root.head, root.head; s != nil; ps, s = &s.next, s.next {
+		if s.addr == a {
+			*ps = s.next
+			unlock(&root.lock)
+			freeLockOwnerRecord(s)
+			return
+		}
+	}
+	unlock(&root.lock)
+}
+
+func newLockOwnerRecord() *lockOwnerRecord {
+	lock(&lockOwners.freeLock)
+	r := lockOwners.free
+	if r != nil {
+		lockOwners.free = r.next
+	}
+	unlock(&lockOwners.freeLock)
+	if r == nil {
+		r = (*lockOwnerRecord)(persistentalloc(unsafe.Sizeof(lockOwnerRecord{}), 0, &memstats.other_sys))
+	}
+	r.next = nil
+	return r
+}
+
+func freeLockOwnerRecord(r *lockOwnerRecord) {
+	r.addr = 0
+	r.lockOwner = lockOwner{}
+	lock(&lockOwners.freeLock)
+	r.next = lockOwners.free
+	lockOwners.free = r
+	unlock(&lockOwners.freeLock)
+}
+
+// lockOwnerOf returns the recorded owner of the lock whose waiters park
+// on addr, if any.
+func lockOwnerOf(addr uintptr) (owner lockOwner, ok bool) {
+	root := lockOwnerRootFor(addr)
+	lock(&root.lock)
+	for s := root.head; s != nil; s = s.next {
+		if s.addr == addr {
+			owner, ok = s.lockOwner, true
+			break
+		}
+	}
+	unlock(&root.lock)
+	return
+}
+
+// exited reports whether the owner has terminated since acquiring the lock.
+func (o *lockOwner) exited() bool {
+	gp := o.gp.ptr()
+	return gp == nil || gp.goid != o.goid || readgstatus(gp)&^_Gscan == _Gdead
+}
+
+// status describes the current state of the owner, in the same terms
+// as goroutine headers in tracebacks. If the owner is blocked, the
+// reason is also returned.
+func (o *lockOwner) status() (string, waitReason) {
+	if o.exited() {
+		return "exited", waitReasonZero
+	}
+	gp := o.gp.ptr()
+	gpstatus := readgstatus(gp) &^ _Gscan
+	if gpstatus >= uint32(len(gStatusStrings)) {
+		return "???", waitReasonZero
+	}
+	switch gpstatus {
+	case _Gwaiting, _Gunreachable, _Gdeadlocked:
+		return gStatusStrings[gpstatus], gp.waitreason
+	}
+	return gStatusStrings[gpstatus], waitReasonZero
+}
+
+// printLockOwner prints the owner of the lock gp is blocked on, if gp is
+// blocked on a lock and its owner is known.
+func printLockOwner(gp *g) {
+	if debug.gcddlockowners == 0 || gp.waiting_sema == nil {
+		return
+	}
+	if owner, ok := lockOwnerOf(uintptr(gcUnmask(gp.waiting_sema))); ok {
+		owner.print()
+	}
+}
+
+// print prints the owner and the stack at which it acquired the lock.
+func (o *lockOwner) print() {
+	status, reason := o.status()
+	print("held by goroutine ", o.goid, " (status ", status)
+	if reason != waitReasonZero {
+		print(", ", reason.String())
+	}
+	print("), acquired at:\n")
+	for fidx, pc := range o.pcs[:o.npcs] {
+		f := findfunc(pc)
+		if !f.valid() {
+			continue
+		}
+		// The recorded pcs are return addresses, one per logical
+		// frame, including inlined ones. Back up to the call
+		// instruction to find the frame it belongs to.
+		u, uf := newInlineUnwinder(f, pc-1)
+		sf := u.srcFunc(uf)
+		if showfuncinfo(sf, fidx == 0, abi.FuncIDNormal) {
+			file, line := u.fileLine(uf)
+			printFuncName(sf.name())
+			print("(...)\n")
+			print("\t", file, ":", line, "\n")
+		}
+	}
+	if o.npcs == lockOwnerDepth {
+		print("...additional frames elided...\n")
+	}
+}
+
+// detectOrphanedLocks reports goroutines blocked on a sync.Mutex, or on
+// the read lock of a sync.RWMutex, whose recorded owner has exited
+// without releasing it, e.g., by returning early without calling Unlock.
+// Such goroutines are usually leaked, but the lock tends to remain
+// reachable from live objects, so partial deadlock detection does not
+// find them. Each goroutine is reported at most once per wait.
+//
+// The world must be stopped.
+func detectOrphanedLocks() {
+	assertWorldStopped()
+
+	forEachGRace(func(gp *g) {
+		if gp.orphanLockReported ||
+			readgstatus(gp) != _Gwaiting ||
+			!gp.waitreason.isMutexWait() ||
+			gp.waiting_sema == nil {
+			return
+		}
+		owner, ok := lockOwnerOf(uintptr(gcUnmask(gp.waiting_sema)))
+		if !ok || !owner.exited() {
+			return
+		}
+		gp.orphanLockReported = true
+		if debug.gcgolfperf != 0 {
+			return
+		}
+		fn := findfunc(gp.startpc)
+		if fn.valid() {
+			print("orphaned lock! goroutine ", gp.goid, ": ", funcname(fn), " [", gp.waitreason.String(), "] Stack size: ", gp.stack.hi-gp.stack.lo, " bytes\n")
+		} else {
+			print("orphaned lock! goroutine ", gp.goid, ": !unnamed goroutine! [", gp.waitreason.String(), "] Stack size: ", gp.stack.hi-gp.stack.lo, " bytes\n")
+		}
+		traceback(gp.sched.pc, gp.sched.sp, gp.sched.lr, gp)
+		owner.print()
+		println()
+	})
+}
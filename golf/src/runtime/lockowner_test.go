@@ -0,0 +1,55 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package runtime_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestLockOwner(t *testing.T) {
+	output := runTestProg(t, "testprog", "LockOwner", "GODEBUG=gcdetectdeadlocks=1,gcddlockowners=1")
+	if !strings.HasSuffix(output, "OK\n") {
+		t.Fatalf("expected OK at the end of the output, got:\n%s", output)
+	}
+
+	// The goroutine holding the lock is deadlocked as well.
+	owner := regexp.MustCompile(`partial deadlock! goroutine (\d+): main\.LockOwner\.func1 \[chan receive\]`).FindStringSubmatch(output)
+	if owner == nil {
+		t.Fatalf("owner of the lock not reported deadlocked:\n%s", output)
+	}
+	want := `(?m)^partial deadlock! goroutine \d+: main\.LockOwner\.func1\.1 \[sync\.Mutex\.Lock\] Stack size: \d+ bytes\n` +
+		`(?:.+\n)+` +
+		`held by goroutine ` + owner[1] + ` \(status unreachable, chan receive\), acquired at:\n` +
+		`main\.LockOwner\.func1\(\.\.\.\)\n` +
+		`\t.+/testprog/lockowner\.go:\d+\n`
+	if !regexp.MustCompile(want).MatchString(output) {
+		t.Fatalf("output does not match %q:\n%s", want, output)
+	}
+}
+
+func TestOrphanedLock(t *testing.T) {
+	output := runTestProg(t, "testprog", "OrphanedLock", "GODEBUG=gcddorphanlocks=1")
+	want := `(?m)^orphaned lock! goroutine \d+: main\.OrphanedLock\.func2 \[sync\.Mutex\.Lock\] Stack size: \d+ bytes\n` +
+		`(?:.+\n)+` +
+		`held by goroutine \d+ \(status exited\), acquired at:\n` +
+		`main\.OrphanedLock\.func1\(\.\.\.\)\n`
+	if !regexp.MustCompile(want).MatchString(output) {
+		t.Fatalf("output does not match %q:\n%s", want, output)
+	}
+	if n := strings.Count(output, "orphaned lock!"); n != 1 {
+		t.Errorf("expected the blocked goroutine to be reported once, got %d reports:\n%s", n, output)
+	}
+}
+
+func TestLockReleasedElsewhere(t *testing.T) {
+	// The lock is released by another goroutine than its exited owner,
+	// so none of the goroutines blocked on it afterwards are orphaned.
+	output := runTestProg(t, "testprog", "LockReleasedElsewhere", "GODEBUG=gcddorphanlocks=1")
+	if want := "OK\n"; output != want {
+		t.Fatalf("output:\n%s\n\nwanted:\n%s", output, want)
+	}
+}
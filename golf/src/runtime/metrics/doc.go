@@ -234,6 +234,15 @@ Below is the full list of supported metrics, ordered lexicographically.
 		The number of non-default behaviors executed by the os/exec
 		package due to a non-default GODEBUG=execerrdot=... setting.
 
+	/godebug/non-default-behavior/gcdetectdeadlocks:events
+		The number of non-default behaviors executed by the runtime
+		package due to a non-default GODEBUG=gcdetectdeadlocks=...
+		setting.
+
+	/godebug/non-default-behavior/gcgolfperf:events
+		The number of non-default behaviors executed by the runtime
+		package due to a non-default GODEBUG=gcgolfperf=... setting.
+
 	/godebug/non-default-behavior/gocachehash:events
 		The number of non-default behaviors executed by the cmd/go
 		package due to a non-default GODEBUG=gocachehash=... setting.
@@ -510,6 +510,9 @@ func GC() {
 		mProf_PostSweep()
 	}
 	releasem(mp)
+
+	// Forced collections sweep without waking the background sweeper.
+	flushGolfNonDefault()
 }
 
 // gcWaitOnMark blocks until GC finishes the Nth mark phase. If GC has
@@ -949,6 +952,10 @@ top:
 		}
 	}
 
+	if debug.gcddorphanlocks > 0 {
+		detectOrphanedLocks()
+	}
+
 	gcComputeStartingStackSize()
 
 	// Disable assists and background workers. We must do
@@ -1084,6 +1091,31 @@ func gcDiscoverMoreStackRoots() {
 	}
 }
 
+var (
+	gcdetectdeadlocksInc = &godebugInc{name: "gcdetectdeadlocks"}
+	gcgolfperfInc        = &godebugInc{name: "gcgolfperf"}
+
+	// golfDeadlocksFound and golfReportsSuppressed count the goroutines
+	// found deadlocked, and those not reported because of gcgolfperf,
+	// since the last call to flushGolfNonDefault.
+	golfDeadlocksFound    atomic.Uint64
+	golfReportsSuppressed atomic.Uint64
+)
+
+// flushGolfNonDefault reports the goroutines counted by
+// detectPartialDeadlocks to the /godebug/non-default-behavior metrics.
+// Deadlocks are detected with the world stopped, where
+// godebugInc.IncNonDefault cannot be called since it may allocate,
+// so the counts are flushed later by the background sweeper, or by GC.
+func flushGolfNonDefault() {
+	for n := golfDeadlocksFound.Swap(0); n > 0; n-- {
+		gcdetectdeadlocksInc.IncNonDefault()
+	}
+	for n := golfReportsSuppressed.Swap(0); n > 0; n-- {
+		gcgolfperfInc.IncNonDefault()
+	}
+}
+
 // detectPartialDeadlocks scans the remaining stackRoots and marks them as unreachable.
 // Returns false if there is still mark work required.
 func detectPartialDeadlocks() bool {
@@ -1126,20 +1158,36 @@ func detectPartialDeadlocks() bool {
 	}
 
 	// For the remaining goroutines, mark them as unreachable and deadlocking.
+	now := nanotime()
 	for i := work.nValidStackRoots; i < work.nStackRoots; i++ {
 		gp := (*g)(gcUnmask(work.stackRoots[i]))
 		casgstatus(gp, _Gwaiting, _Gunreachable)
-		if debug.gcgolfperf == 0 {
+		// Unreachable stacks are not scanned by markroot in this cycle,
+		// so remember when we've first observed the G blocked here.
+		if gp.waitsince == 0 {
+			gp.waitsince = now
+		}
+		work.stackRoots[i] = unsafe.Pointer(gp)
+	}
+	golfDeadlocksFound.Add(int64(work.nStackRoots - work.nValidStackRoots))
+	if debug.gcgolfperf != 0 {
+		golfReportsSuppressed.Add(int64(work.nStackRoots - work.nValidStackRoots))
+	}
+	// Report them only once all of them are marked, so that reported
+	// lock owners show up as unreachable if they are deadlocked as well.
+	if debug.gcgolfperf == 0 {
+		for i := work.nValidStackRoots; i < work.nStackRoots; i++ {
+			gp := (*g)(work.stackRoots[i])
 			fn := findfunc(gp.startpc)
 			if fn.valid() {
-				print("partial deadlock! goroutine ", gp.goid, ": ", funcname(fn), " Stack size: ", gp.stack.hi-gp.stack.lo, " bytes\n")
+				print("partial deadlock! goroutine ", gp.goid, ": ", funcname(fn), " [", gp.waitreason.String(), "] Stack size: ", gp.stack.hi-gp.stack.lo, " bytes\n")
 			} else {
-				print("partial deadlock! goroutine ", gp.goid, ": !unnamed goroutine!", " Stack size: ", gp.stack.hi-gp.stack.lo, " bytes\n")
+				print("partial deadlock! goroutine ", gp.goid, ": !unnamed goroutine! [", gp.waitreason.String(), "] Stack size: ", gp.stack.hi-gp.stack.lo, " bytes\n")
 			}
 			traceback(gp.sched.pc, gp.sched.sp, gp.sched.lr, gp)
+			printLockOwner(gp)
 			println()
 		}
-		work.stackRoots[i] = unsafe.Pointer(gp)
 	}
 	// Put the remaining roots as ready for marking and drain them.
 	work.markrootJobs += uint32(work.nStackRoots - work.nValidStackRoots)
@@ -256,7 +256,7 @@ func gcGoexit(gp *g) {
 	if gp.lockedm != 0 {
 		throw("Unreachable goroutine has locked a thread!")
 	}
-	if mp.lockedg != 0 {
+	if mp.lockedg.ptr() == gp {
 		throw("Not sure what to do here!")
 	}
 
@@ -300,6 +300,7 @@ func gcGoexit(gp *g) {
 	gp.writebuf = nil
 	gp.waiting_sema = nil
 	gp.waiting_notifier = nil
+	gp.orphanLockReported = false
 	gp.param = nil
 	gp.labels = nil
 	gp.timer = nil
@@ -390,10 +391,12 @@ func markroot(gcw *gcWork, i uint32, flags gcDrainFlags) int64 {
 		gp := (*g)(work.stackRoots[i-work.baseStacks])
 
 		// remember when we've first observed the G blocked
-		// needed only to output in traceback
+		// needed to output in traceback, and by BlockedGoroutines
+		// as a lower bound on the blocking time, so this cannot be
+		// work.tstart, which is set at the previous mark termination.
 		status := readgstatus(gp) // We are not in a scan state
 		if (status == _Gwaiting || status == _Gsyscall) && gp.waitsince == 0 {
-			gp.waitsince = work.tstart
+			gp.waitsince = nanotime()
 		}
 
 		// scanstack must be done on the system stack in case
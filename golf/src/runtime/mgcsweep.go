@@ -306,6 +306,7 @@ func bgsweep(c chan int) {
 			// N.B. freeSomeWbufs is already batched internally.
 			goschedIfBusy()
 		}
+		flushGolfNonDefault()
 		lock(&sweep.lock)
 		if !isSweepDone() {
 			// This can happen if a GC runs between
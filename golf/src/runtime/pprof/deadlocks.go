@@ -0,0 +1,97 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pprof
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"runtime"
+)
+
+var deadlocksProfile = &Profile{
+	name:  "deadlocks",
+	count: countDeadlocks,
+	write: writeDeadlocks,
+}
+
+// deadlockRecord must be kept in sync with runtime.blockedGoroutineRecord.
+type deadlockRecord struct {
+	id         uint64
+	waitReason string
+	waitTime   int64
+	deadlocked bool
+	stack      runtime.StackRecord
+	startPC    uintptr
+	stackSize  uintptr
+}
+
+// runtime_readDeadlockedGoroutines is defined in runtime/deadlockdebug.go.
+func runtime_readDeadlockedGoroutines(p []deadlockRecord) (n int, ok bool)
+
+// countDeadlocks returns the number of deadlocked goroutines.
+func countDeadlocks() int {
+	n, _ := runtime_readDeadlockedGoroutines(nil)
+	return n
+}
+
+// readDeadlocks returns the goroutines found deadlocked by the garbage
+// collector, in the order of the runtime's list of goroutines.
+func readDeadlocks() []deadlockRecord {
+	var p []deadlockRecord
+	n, ok := runtime_readDeadlockedGoroutines(nil)
+	for !ok {
+		// Allow room for a few more goroutines found deadlocked
+		// by a garbage collection in the meantime.
+		p = make([]deadlockRecord, n+10)
+		n, ok = runtime_readDeadlockedGoroutines(p)
+	}
+	return p[:n]
+}
+
+// writeDeadlocks writes the deadlocks profile to w.
+// With debug > 0, each goroutine is listed with its stack,
+// in the format of the runtime's partial deadlock reports.
+func writeDeadlocks(w io.Writer, debug int) error {
+	rs := readDeadlocks()
+	if debug > 0 {
+		return printDeadlocks(w, rs)
+	}
+	stks := make(stackProfile, len(rs))
+	for i := range rs {
+		stks[i] = rs[i].stack.Stack()
+	}
+	return printCountProfile(w, debug, "deadlocks", stks)
+}
+
+func printDeadlocks(w io.Writer, rs []deadlockRecord) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "deadlocks profile: total %d\n", len(rs))
+	for _, r := range rs {
+		name := "!unnamed goroutine!"
+		if f := runtime.FuncForPC(r.startPC); f != nil {
+			name = f.Name()
+		}
+		fmt.Fprintf(b, "\npartial deadlock! goroutine %d: %s [%s] Stack size: %d bytes\n", r.id, name, r.waitReason, r.stackSize)
+		frames := runtime.CallersFrames(r.stack.Stack())
+		for {
+			frame, more := frames.Next()
+			if frame.Function != "runtime.goexit" {
+				fmt.Fprintf(b, "%s(...)\n\t%s:%d", frame.Function, frame.File, frame.Line)
+				if frame.Func != nil {
+					// Like the runtime, print the offset of the return
+					// address, which CallersFrames backs up by one,
+					// except for inlined calls.
+					fmt.Fprintf(b, " +%#x", frame.PC+1-frame.Entry)
+				}
+				fmt.Fprintln(b)
+			}
+			if !more {
+				break
+			}
+		}
+	}
+	return b.Flush()
+}
@@ -105,6 +105,7 @@ import (
 //	threadcreate - stack traces that led to the creation of new OS threads
 //	block        - stack traces that led to blocking on synchronization primitives
 //	mutex        - stack traces of holders of contended mutexes
+//	deadlocks    - stack traces of goroutines found deadlocked by the garbage collector
 //
 // These predefined profiles maintain themselves and panic on an explicit
 // [Profile.Add] or [Profile.Remove] method call.
@@ -169,6 +170,15 @@ import (
 // runtime-internal locks can be obtained by setting
 // `GODEBUG=runtimecontentionstacks=1` (see package [runtime] docs for
 // caveats).
+//
+// # Deadlocks profile
+//
+// The deadlocks profile lists the goroutines that the garbage collector
+// found blocked forever, under GODEBUG=gcdetectdeadlocks=1 or 2.
+// Under gcdetectdeadlocks=1, goroutines are only listed until the next
+// garbage collection reclaims them.
+// With debug > 0, each goroutine is written separately, in the format of
+// the reports the runtime prints when it finds a partial deadlock.
 type Profile struct {
 	name  string
 	mu    sync.Mutex
@@ -230,6 +240,7 @@ func lockProfiles() {
 			"allocs":       allocsProfile,
 			"block":        blockProfile,
 			"mutex":        mutexProfile,
+			"deadlocks":    deadlocksProfile,
 		}
 	}
 }
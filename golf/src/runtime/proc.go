@@ -1282,6 +1282,8 @@ const (
 	stwForTestReadMemStatsSlow                      // "ReadMemStatsSlow (test)"
 	stwForTestPageCachePagesLeaked                  // "PageCachePagesLeaked (test)"
 	stwForTestResetDebugLog                         // "ResetDebugLog (test)"
+	stwReclaimDeadlocked                            // "reclaim deadlocked goroutines"
+	stwEnableLeakCheck                              // "enable leak check"
 )
 
 func (r stwReason) String() string {
@@ -1313,6 +1315,8 @@ var stwReasonStrings = [...]string{
 	stwForTestReadMemStatsSlow:     "ReadMemStatsSlow (test)",
 	stwForTestPageCachePagesLeaked: "PageCachePagesLeaked (test)",
 	stwForTestResetDebugLog:        "ResetDebugLog (test)",
+	stwReclaimDeadlocked:           "reclaim deadlocked goroutines",
+	stwEnableLeakCheck:             "enable leak check",
 }
 
 // worldStop provides context from the stop-the-world required by the
@@ -4940,6 +4944,7 @@ func newproc1(fn *funcval, callergp *g, callerpc uintptr) *g {
 	newg.parentGoid = callergp.goid
 	newg.gopc = callerpc
 	newg.ancestors = saveAncestors(callergp)
+	newg.ancestryID = callergp.ancestryID
 	newg.startpc = fn.fn
 	if isSystemGoroutine(newg, false) {
 		sched.ngsys.Add(1)
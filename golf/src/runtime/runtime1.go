@@ -313,6 +313,8 @@ var debug struct {
 	dontfreezetheworld      int32
 	efence                  int32
 	gccheckmark             int32
+	gcddlockowners          int32 // Track sync.Mutex owners for deadlock reports
+	gcddorphanlocks         int32 // Report goroutines blocked on locks held by exited goroutines
 	gcdetectdeadlocks       int32 // Detect deadlocks during GC
 	gcgolfperf              int32 // Run Golf in performance mode. Disable GC
 	gcpacertrace            int32
@@ -351,6 +353,8 @@ var dbgvars = []*dbgVar{
 	{name: "dontfreezetheworld", value: &debug.dontfreezetheworld},
 	{name: "efence", value: &debug.efence},
 	{name: "gccheckmark", value: &debug.gccheckmark},
+	{name: "gcddlockowners", value: &debug.gcddlockowners},
+	{name: "gcddorphanlocks", value: &debug.gcddorphanlocks},
 	{name: "gcdetectdeadlocks", value: &debug.gcdetectdeadlocks},
 	{name: "gcgolfperf", value: &debug.gcgolfperf},
 	{name: "gcpacertrace", value: &debug.gcpacertrace},
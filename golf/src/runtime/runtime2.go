@@ -491,6 +491,10 @@ type g struct {
 	// Used by the execution tracer.
 	inMarkAssist bool
 	coroexit     bool // argument to coroswitch_m
+	// orphanLockReported indicates that the goroutine was reported as
+	// blocked on a lock held by an exited goroutine while in its current
+	// wait (only used if debug.gcddorphanlocks).
+	orphanLockReported bool
 
 	raceignore    int8  // ignore race detection events
 	nocgocallback bool  // whether disable callback from C
@@ -507,6 +511,7 @@ type g struct {
 	parentGoid    uint64          // goid of goroutine that created this goroutine
 	gopc          uintptr         // pc of go statement that created this goroutine
 	ancestors     *[]ancestorInfo // ancestor information goroutine(s) that created this goroutine (only used if debug.tracebackancestors)
+	ancestryID    uint64          // inherited from the creating goroutine unless reset; identifies the test that created this goroutine (only used by go test -leakcheck)
 	startpc       uintptr         // pc of goroutine function
 	racectx       uintptr
 	waiting       *sudog         // sudog structures this g is waiting on (that have a valid elem ptr); in lock order
@@ -103,6 +103,7 @@ func readyWithTime(s *sudog, traceskip int) {
 	}
 	s.g.waiting_sema = nil
 	s.g.waiting_notifier = nil
+	s.g.orphanLockReported = false
 	goready(s.g, traceskip)
 }
 
@@ -0,0 +1,108 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+func init() {
+	register("LockOwner", LockOwner)
+	register("OrphanedLock", OrphanedLock)
+	register("LockReleasedElsewhere", LockReleasedElsewhere)
+}
+
+// paddedMutex is a mutex too large for the tiny allocator, which may
+// otherwise place it in a block shared with reachable objects, keeping
+// the goroutines blocked on it from being found deadlocked.
+type paddedMutex struct {
+	sync.Mutex
+	_ [16]byte
+}
+
+// LockOwner leaks a goroutine blocked on a mutex held by another leaked
+// goroutine. With GODEBUG=gcddlockowners=1, the partial deadlock report
+// of the former names the latter as the owner of the mutex.
+func LockOwner() {
+	go func() {
+		mu := new(paddedMutex)
+		mu.Lock()
+		go func() {
+			mu.Lock()
+		}()
+		<-make(chan struct{})
+	}()
+	for i := 0; i < 10; i++ {
+		time.Sleep(10 * time.Millisecond)
+		runtime.GC()
+	}
+	fmt.Println("OK")
+}
+
+// OrphanedLock leaks a goroutine blocked on a mutex that another goroutine
+// returned without unlocking. With GODEBUG=gcddorphanlocks=1, the former
+// is reported, although the mutex remains reachable.
+func OrphanedLock() {
+	var mu sync.Mutex
+	locked := make(chan struct{})
+	go func() {
+		mu.Lock()
+		close(locked)
+	}()
+	<-locked
+	go func() {
+		mu.Lock()
+	}()
+	for i := 0; i < 10; i++ {
+		time.Sleep(10 * time.Millisecond)
+		runtime.GC()
+	}
+	runtime.KeepAlive(&mu)
+	fmt.Println("OK")
+}
+
+// LockReleasedElsewhere unlocks a lock acquired by a goroutine that has
+// since exited. The goroutines blocked on it afterwards must not be
+// reported as blocked on an orphaned lock.
+func LockReleasedElsewhere() {
+	var rw sync.RWMutex
+	locked := make(chan struct{})
+	go func() {
+		rw.Lock()
+		close(locked)
+	}()
+	<-locked
+	time.Sleep(10 * time.Millisecond)
+	rw.Unlock()
+
+	rw.RLock()
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		rw.Lock()
+		rw.Unlock()
+	}()
+	// Wait for the writer to be pending, so that new readers queue.
+	for rw.TryRLock() {
+		rw.RUnlock()
+		time.Sleep(time.Millisecond)
+	}
+	go func() {
+		defer wg.Done()
+		rw.RLock()
+		rw.RUnlock()
+	}()
+	for i := 0; i < 5; i++ {
+		time.Sleep(10 * time.Millisecond)
+		runtime.GC()
+	}
+	rw.RUnlock()
+	wg.Wait()
+	fmt.Println("OK")
+}
@@ -80,7 +80,8 @@ const (
 // blocks until the mutex is available.
 func (m *Mutex) Lock() {
 	// Fast path: grab unlocked mutex.
-	if atomic.CompareAndSwapInt32(&m.state, 0, mutexLocked) {
+	// Lock owners are only recorded on the slow path.
+	if !lockOwnerTracking && atomic.CompareAndSwapInt32(&m.state, 0, mutexLocked) {
 		if race.Enabled {
 			race.Acquire(unsafe.Pointer(m))
 		}
@@ -108,6 +109,9 @@ func (m *Mutex) TryLock() bool {
 		return false
 	}
 
+	if lockOwnerTracking {
+		runtime_lockAcquired(&m.sema, 1)
+	}
 	if race.Enabled {
 		race.Acquire(unsafe.Pointer(m))
 	}
@@ -198,6 +202,9 @@ func (m *Mutex) lockSlow() {
 		}
 	}
 
+	if lockOwnerTracking {
+		runtime_lockAcquired(&m.sema, 2)
+	}
 	if race.Enabled {
 		race.Acquire(unsafe.Pointer(m))
 	}
@@ -216,8 +223,12 @@ func (m *Mutex) Unlock() {
 	}
 
 	// Fast path: drop lock bit.
-	new := atomic.AddInt32(&m.state, -mutexLocked)
-	if new != 0 {
+	// Lock owners are forgotten on the slow path, before the lock bit is dropped.
+	var new int32
+	if !lockOwnerTracking {
+		new = atomic.AddInt32(&m.state, -mutexLocked)
+	}
+	if new != 0 || lockOwnerTracking {
 		// Outlined slow path to allow inlining the fast path.
 		// To hide unlockSlow during tracing we skip one extra frame when tracing GoUnblock.
 		m.unlockSlow(new)
@@ -225,6 +236,14 @@ func (m *Mutex) Unlock() {
 }
 
 func (m *Mutex) unlockSlow(new int32) {
+	if lockOwnerTracking {
+		// Once the lock bit is dropped, another goroutine may acquire
+		// the lock and record itself as its owner.
+		runtime_lockReleased(&m.sema)
+		if new = atomic.AddInt32(&m.state, -mutexLocked); new == 0 {
+			return
+		}
+	}
 	if (new+mutexLocked)&mutexLocked == 0 {
 		fatal("sync: unlock of unlocked mutex")
 	}
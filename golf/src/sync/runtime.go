@@ -38,6 +38,23 @@ func runtime_SemacquireRWMutex(s *uint32, lifo bool, skipframes int)
 // runtime_Semrelease's caller.
 func runtime_Semrelease(s *uint32, handoff bool, skipframes int)
 
+// lockOwnerTracking is set if the runtime should be told about every
+// acquisition and release of a Mutex, or the write lock of an RWMutex,
+// so that it can report lock owners (GODEBUG=gcddlockowners=1).
+var lockOwnerTracking = runtime_lockOwnerTracking()
+
+// See runtime/lockowner.go for documentation.
+func runtime_lockOwnerTracking() bool
+
+// lockAcquired records the calling goroutine as the owner of the lock
+// whose waiters park on s.
+// skipframes is the number of frames to omit from the recorded stack,
+// counting from runtime_lockAcquired's caller.
+func runtime_lockAcquired(s *uint32, skipframes int)
+
+// lockReleased forgets the owner of the lock whose waiters park on s.
+func runtime_lockReleased(s *uint32)
+
 // See runtime/sema.go for documentation.
 func runtime_notifyListAdd(l *notifyList) uint32
 
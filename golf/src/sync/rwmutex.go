@@ -150,6 +150,9 @@ func (rw *RWMutex) Lock() {
 	if r != 0 && rw.readerWait.Add(r) != 0 {
 		runtime_SemacquireRWMutex(&rw.writerSem, false, 0)
 	}
+	if lockOwnerTracking {
+		runtime_lockAcquired(&rw.readerSem, 1)
+	}
 	if race.Enabled {
 		race.Enable()
 		race.Acquire(unsafe.Pointer(&rw.readerSem))
@@ -180,6 +183,9 @@ func (rw *RWMutex) TryLock() bool {
 		}
 		return false
 	}
+	if lockOwnerTracking {
+		runtime_lockAcquired(&rw.readerSem, 1)
+	}
 	if race.Enabled {
 		race.Enable()
 		race.Acquire(unsafe.Pointer(&rw.readerSem))
@@ -201,6 +207,9 @@ func (rw *RWMutex) Unlock() {
 		race.Disable()
 	}
 
+	if lockOwnerTracking {
+		runtime_lockReleased(&rw.readerSem)
+	}
 	// Announce to readers there is no active writer.
 	r := rw.readerCount.Add(rwmutexMaxReaders)
 	if r >= rwmutexMaxReaders {
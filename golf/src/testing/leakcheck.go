@@ -0,0 +1,148 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package testing
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync/atomic"
+	_ "unsafe" // for go:linkname
+)
+
+// Implemented in package runtime.
+//
+//go:linkname runtime_enableLeakCheck
+func runtime_enableLeakCheck()
+
+//go:linkname runtime_setAncestryID
+func runtime_setAncestryID(id uint64)
+
+//go:linkname runtime_readDeadlockedGoroutineIDs
+func runtime_readDeadlockedGoroutineIDs(p []uint64, ancestryID uint64) (n int, ok bool)
+
+// leakCheckGCs is the number of garbage collections run after a test
+// to find goroutines it leaked. Goroutines started by the test may not
+// have blocked yet when it finishes, so a single collection could miss them.
+const leakCheckGCs = 2
+
+// lastAncestryID is the last ancestry ID handed out to a test.
+var lastAncestryID atomic.Uint64
+
+// resetLeaks gives t a fresh ancestry ID, which is inherited by every
+// goroutine the calling goroutine creates from now on, and transitively
+// by the goroutines they create. Subtests start with IDs of their own,
+// so that they are not blamed for each other's leaks.
+func (t *T) resetLeaks() {
+	if !*leakCheck {
+		return
+	}
+	t.ancestryID = lastAncestryID.Add(1)
+	runtime_setAncestryID(t.ancestryID)
+}
+
+// LeakedGoroutines returns the goroutines created by the test, directly
+// or through other goroutines, but not by its subtests, that are
+// deadlocked. It runs garbage collections to find them, which only
+// succeeds if the -test.leakcheck flag is set; otherwise it returns nil.
+//
+// With -test.leakcheck, the test fails if LeakedGoroutines returns
+// a non-empty result once the test and its cleanups have finished.
+func (t *T) LeakedGoroutines() []debug.BlockedGoroutine {
+	if !*leakCheck {
+		return nil
+	}
+	for i := 0; i < leakCheckGCs; i++ {
+		runtime.Gosched()
+		runtime.GC()
+	}
+	ids := t.deadlockedGoroutineIDs()
+	if len(ids) == 0 {
+		return nil
+	}
+	var leaked []debug.BlockedGoroutine
+	for _, g := range debug.DeadlockedGoroutines() {
+		if ids[g.ID] {
+			leaked = append(leaked, g)
+		}
+	}
+	return leaked
+}
+
+// deadlockedGoroutineIDs returns the set of IDs of the deadlocked
+// goroutines that descend from t.
+func (t *T) deadlockedGoroutineIDs() map[uint64]bool {
+	var p []uint64
+	n, ok := runtime_readDeadlockedGoroutineIDs(nil, t.ancestryID)
+	for !ok {
+		p = make([]uint64, n+10)
+		n, ok = runtime_readDeadlockedGoroutineIDs(p, t.ancestryID)
+	}
+	if n == 0 {
+		return nil
+	}
+	ids := make(map[uint64]bool, n)
+	for _, id := range p[:n] {
+		ids[id] = true
+	}
+	return ids
+}
+
+// checkLeaks reports the goroutines leaked by t, as found by
+// LeakedGoroutines once t and its cleanups have finished, if
+// -test.leakcheck is set, and marks t as failed if there are any.
+//
+// Reported goroutines are reclaimed, so that each is reported at most once.
+// The failure is attributed to the function the first of them was started
+// with, rather than to checkLeaks.
+func (t *T) checkLeaks() {
+	var leaked []debug.BlockedGoroutine
+	for _, g := range t.LeakedGoroutines() {
+		if debug.ReclaimDeadlockedGoroutine(g.ID) {
+			leaked = append(leaked, g)
+		}
+	}
+	if len(leaked) == 0 {
+		return
+	}
+
+	var b strings.Builder
+	var startPC []uintptr
+	fmt.Fprintf(&b, "%d goroutine(s) deadlocked during execution of test:", len(leaked))
+	for _, g := range leaked {
+		fmt.Fprintf(&b, "\n\ngoroutine %d [%s]:", g.ID, g.WaitReason)
+		frames := runtime.CallersFrames(g.Stack)
+		for {
+			frame, more := frames.Next()
+			if !strings.HasPrefix(frame.Function, "runtime.") {
+				fmt.Fprintf(&b, "\n%s(...)\n\t%s:%d", frame.Function, frame.File, frame.Line)
+				if g.ID == leaked[0].ID {
+					// The outermost frame is the function the goroutine was started with.
+					startPC = []uintptr{frame.PC}
+				}
+			}
+			if !more {
+				break
+			}
+		}
+	}
+
+	if startPC != nil {
+		// Like the frames of a cleanup function, frameSkip replaces
+		// the frames of checkLeaks, here with the goroutine's start.
+		t.mu.Lock()
+		t.cleanupName = callerName(0)
+		t.cleanupPc = startPC
+		t.mu.Unlock()
+		defer func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.cleanupName = ""
+			t.cleanupPc = nil
+		}()
+	}
+	t.Errorf("%s", b.String())
+}
@@ -0,0 +1,160 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package testing_test
+
+import (
+	"flag"
+	"internal/testenv"
+	"os"
+	"regexp"
+	"runtime/debug"
+	"testing"
+	"time"
+)
+
+func leakCheckHelper(t *testing.T) {
+	t.Run("leak", func(t *testing.T) {
+		go func() {
+			<-make(chan int)
+		}()
+	})
+	t.Run("clean", func(t *testing.T) {
+		done := make(chan int)
+		go func() {
+			close(done)
+		}()
+		<-done
+	})
+	t.Run("blocked", func(t *testing.T) {
+		// Goroutines blocked on channels still reachable
+		// when the test finishes are not deadlocked.
+		ch := make(chan int)
+		go func() {
+			<-ch
+		}()
+		t.Cleanup(func() { close(ch) })
+	})
+	t.Run("reclaimed", func(t *testing.T) {
+		go func() {
+			<-make(chan int)
+		}()
+		leaked := t.LeakedGoroutines()
+		if len(leaked) != 1 {
+			t.Fatalf("LeakedGoroutines returned %d goroutines, want 1", len(leaked))
+		}
+		if !debug.ReclaimDeadlockedGoroutine(leaked[0].ID) {
+			t.Fatalf("ReclaimDeadlockedGoroutine(%d) = false", leaked[0].ID)
+		}
+	})
+}
+
+func TestLeakCheck(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") == "1" {
+		leakCheckHelper(t)
+		return
+	}
+
+	testenv.MustHaveExec(t)
+	t.Parallel()
+
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := testenv.Command(t, exe, "-test.run=^TestLeakCheck$", "-test.leakcheck")
+	cmd = testenv.CleanCmdEnv(cmd)
+	cmd.Env = append(cmd.Env, "GO_WANT_HELPER_PROCESS=1")
+	out, _ := cmd.CombinedOutput()
+
+	// Only the leaking subtest fails, with the failure attributed to the
+	// goroutine it started.
+	want := `^--- FAIL: TestLeakCheck \([^)]+\)
+    --- FAIL: TestLeakCheck/leak \([^)]+\)
+        leakcheck_test.go:20: 1 goroutine\(s\) deadlocked during execution of test:
+ *
+            goroutine \d+ \[chan receive\]:
+            testing_test.leakCheckHelper.func1.1\(...\)
+            	.*/leakcheck_test.go:20
+FAIL
+$`
+	if !regexp.MustCompile(want).Match(out) {
+		t.Errorf("got output:\n\n%s\nwant matching:\n\n%s", out, want)
+	}
+}
+
+func leakCheckParallelHelper(t *testing.T) {
+	leaked := make(chan int)
+	t.Run("leak", func(t *testing.T) {
+		t.Parallel()
+		// Goroutines inherit the test of the goroutine that created them,
+		// even if it has exited.
+		go func() {
+			go func() {
+				<-make(chan int)
+			}()
+		}()
+		for len(t.LeakedGoroutines()) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		close(leaked)
+	})
+	t.Run("clean", func(t *testing.T) {
+		t.Parallel()
+		// Run while the other subtest has leaked a goroutine,
+		// which must not be blamed on this one.
+		<-leaked
+		if n := len(t.LeakedGoroutines()); n != 0 {
+			t.Errorf("LeakedGoroutines returned %d goroutines, want 0", n)
+		}
+	})
+}
+
+func TestLeakCheckParallel(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") == "1" {
+		leakCheckParallelHelper(t)
+		return
+	}
+
+	testenv.MustHaveExec(t)
+	t.Parallel()
+
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := testenv.Command(t, exe, "-test.run=^TestLeakCheckParallel$", "-test.leakcheck")
+	cmd = testenv.CleanCmdEnv(cmd)
+	cmd.Env = append(cmd.Env, "GO_WANT_HELPER_PROCESS=1")
+	out, _ := cmd.CombinedOutput()
+
+	// Only the leaking subtest fails, with the failure attributed to the
+	// goroutine it started.
+	want := `^--- FAIL: TestLeakCheckParallel \([^)]+\)
+    --- FAIL: TestLeakCheckParallel/leak \([^)]+\)
+        leakcheck_test.go:96: 1 goroutine\(s\) deadlocked during execution of test:
+ *
+            goroutine \d+ \[chan receive\]:
+            testing_test.leakCheckParallelHelper.func1.1.1\(...\)
+            	.*/leakcheck_test.go:96
+FAIL
+$`
+	if !regexp.MustCompile(want).Match(out) {
+		t.Errorf("got output:\n\n%s\nwant matching:\n\n%s", out, want)
+	}
+}
+
+func TestLeakedGoroutinesWithoutLeakCheck(t *testing.T) {
+	if f := flag.Lookup("test.leakcheck"); f.Value.String() != "false" {
+		t.Skip("-test.leakcheck is set")
+	}
+	go func() {
+		<-make(chan int)
+	}()
+	if leaked := t.LeakedGoroutines(); leaked != nil {
+		t.Errorf("LeakedGoroutines returned %d goroutines without -test.leakcheck, want nil", len(leaked))
+	}
+}
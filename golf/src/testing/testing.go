@@ -442,6 +442,7 @@ func Init() {
 	testlog = flag.String("test.testlogfile", "", "write test action log to `file` (for use only by cmd/go)")
 	shuffle = flag.String("test.shuffle", "off", "randomize the execution order of tests and benchmarks")
 	fullPath = flag.Bool("test.fullpath", false, "show full file names in error messages")
+	leakCheck = flag.Bool("test.leakcheck", false, "fail tests that leave goroutines deadlocked")
 
 	initBenchmarkFlags()
 	initFuzzFlags()
@@ -474,6 +475,7 @@ var (
 	shuffle              *string
 	testlog              *string
 	fullPath             *bool
+	leakCheck            *bool
 
 	haveExamples bool // are there examples?
 
@@ -918,6 +920,8 @@ type T struct {
 	common
 	isEnvSet bool
 	context  *testContext // For running tests and subtests.
+
+	ancestryID uint64 // Inherited by goroutines created by the test, if -test.leakcheck is set.
 }
 
 func (c *common) private() {}
@@ -1545,6 +1549,9 @@ func tRunner(t *T, fn func(t *T)) {
 	// a signal saying that the test is done.
 	defer func() {
 		t.checkRaces()
+		if len(t.sub) == 0 {
+			t.checkLeaks()
+		}
 
 		// TODO(#61034): This is the wrong place for this check.
 		if t.Failed() {
@@ -1660,6 +1667,7 @@ func tRunner(t *T, fn func(t *T)) {
 				doPanic(err)
 			}
 			t.checkRaces()
+			t.checkLeaks()
 			if !t.isParallel {
 				// Reacquire the count for sequential tests. See comment in Run.
 				t.context.waitParallel()
@@ -1686,6 +1694,7 @@ func tRunner(t *T, fn func(t *T)) {
 
 	t.start = time.Now()
 	t.resetRaces()
+	t.resetLeaks()
 	fn(t)
 
 	// code beyond here will not be executed when FailNow is invoked
@@ -2178,6 +2187,9 @@ func (m *M) before() {
 	if *memProfileRate > 0 {
 		runtime.MemProfileRate = *memProfileRate
 	}
+	if *leakCheck {
+		runtime_enableLeakCheck()
+	}
 	if *cpuProfile != "" {
 		f, err := os.Create(toOutputDir(*cpuProfile))
 		if err != nil {
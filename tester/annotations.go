@@ -5,9 +5,14 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"regexp"
 	"strings"
 )
 
+// annotation matches deadlock annotations, with an optional configuration
+// guard in brackets, e.g., `deadlocks[GOMAXPROCS=1]: 0`.
+var annotation = regexp.MustCompile(`^deadlocks(?:\[([^\]]*)\])?:(.*)$`)
+
 type ExpectedDeadlock struct {
 	token.Position
 
@@ -15,12 +20,27 @@ type ExpectedDeadlock struct {
 	// It follows the naming convention of named functions in Go traces.
 	FunctionName string
 
+	// GoStatement is true if the annotation is at a go statement. Such
+	// annotations are matched with the deadlocked goroutines created at
+	// the position of the go statement, instead of by function name.
+	GoStatement bool
+
 	// Expression quantifies the number of deadlocks expected to occur in the
 	// trace. The expression may evaluate to either a numeric constant, in
 	// which case it is the exact number of deadlocks expected, or it may
 	// be a boolean expression that the number of expected deadlocks must
 	// satisfy.
 	Expression ast.Expr
+
+	// WaitReason is the reason the deadlocked goroutines are expected to be
+	// blocked for, as reported by the runtime, e.g., "chan send". It is
+	// given after the expression, as in `deadlocks: x > 0 on chan send`.
+	// If empty, any wait reason is accepted.
+	WaitReason string
+
+	// Test is the name of the Test function enclosing the annotation
+	// in a _test.go file, if any.
+	Test string
 }
 
 type ExpectedDeadlocks struct {
@@ -42,12 +62,39 @@ type expectedDeadlockVisitor struct {
 	ast.CommentMap
 	ExpectedDeadlocks
 
-	FunctionName           string
-	Receiver               ast.Node
-	FunctionLiteralNesting functionLiteralNesting
+	// Symbols maps function positions to the symbol names of the
+	// compiled target. It may be nil, in which case only named functions
+	// are resolved.
+	Symbols *SymbolTable
+
+	// Qualifier is the package qualifier of the symbols of the visited file,
+	// e.g., "main" or "example.com/pkg". If empty, the package name is used.
+	Qualifier string
+	// Scopes is the stack of functions enclosing the visited node.
+	Scopes []*functionScope
+	// Test is the name of the Test function enclosing the visited node, if any.
+	Test string
+}
+
+// functionScope is a function declaration or literal enclosing an annotation.
+type functionScope struct {
+	// Symbol is the symbol name of the function in the compiled target,
+	// or "" if it could not be resolved.
+	Symbol string
+	// Goroutine is the symbol name of the function that a goroutine
+	// runs if the function is a literal started by a go statement.
+	// It differs from Symbol if the compiler wrapped the call.
+	Goroutine string
+
+	// literals and gowraps count the function literals and go statements
+	// visited so far at each line of the function.
+	literals, gowraps map[int]int
 }
 
 func (dl ExpectedDeadlock) String() string {
+	if dl.WaitReason != "" {
+		return fmt.Sprintf("Function: %s; Validation expression: %s on %s", dl.FunctionName, AstString(dl.Expression), dl.WaitReason)
+	}
 	return fmt.Sprintf("Function: %s; Validation expression: %s", dl.FunctionName, AstString(dl.Expression))
 }
 func (dls ExpectedDeadlocks) String() string {
@@ -61,130 +108,205 @@ func (dls ExpectedDeadlocks) String() string {
 		strings.Join(dlsStr, "\n\t"))
 }
 
-// GetFunctionName constructs a
-func (v *expectedDeadlockVisitor) GetFunctionName() string {
-	functionName := v.Package.Name + ReceiverToString(v.Receiver)
-	if v.FunctionName == "" {
-		functionName += ".init"
-	} else {
-		functionName += "." + v.FunctionName
+// qualifier returns the package qualifier of the symbols of the visited file.
+func (v *expectedDeadlockVisitor) qualifier() string {
+	if v.Qualifier != "" {
+		return v.Qualifier
 	}
-	return functionName + v.FunctionLiteralNesting.FunctionSuffix()
+	return v.Package.Name
 }
 
-// Visit allows expectedDeadlockVisitor to satisfy the ast.Visitor interface.
-func (v *expectedDeadlockVisitor) Visit(node ast.Node) ast.Visitor {
-	if node == nil {
-		return nil
+// ForTest returns the expectations for a run of the given Test function of
+// a test package, which excludes the annotations in other Test functions.
+func (dls ExpectedDeadlocks) ForTest(name string) ExpectedDeadlocks {
+	deadlocks := make([]ExpectedDeadlock, 0, len(dls.Deadlocks))
+	for _, dl := range dls.Deadlocks {
+		if dl.Test == "" || dl.Test == name {
+			deadlocks = append(deadlocks, dl)
+		}
 	}
+	dls.Deadlocks = deadlocks
+	return dls
+}
 
-	functionScope := func(n ...ast.Node) {
-		v.FunctionLiteralNesting = append(v.FunctionLiteralNesting, &funcLiteral{
-			fun:    1,
-			gowrap: 1,
+// scope returns the innermost function enclosing the visited node.
+// Package-level nodes are enclosed by the package initializer.
+func (v *expectedDeadlockVisitor) scope() *functionScope {
+	if len(v.Scopes) == 0 {
+		v.Scopes = append(v.Scopes, &functionScope{
+			Symbol:   v.qualifier() + ".init",
+			literals: make(map[int]int),
+			gowraps:  make(map[int]int),
 		})
-		for _, node := range n {
-			ast.Walk(v, node)
-		}
-		v.FunctionLiteralNesting = v.FunctionLiteralNesting[:len(v.FunctionLiteralNesting)-1]
+	}
+	return v.Scopes[len(v.Scopes)-1]
+}
+
+// GetFunctionName returns the name of the function expected to deadlock
+// for an annotation in the innermost enclosing function. Annotations
+// in function literals started by go statements refer to the goroutine.
+func (v *expectedDeadlockVisitor) GetFunctionName() string {
+	s := v.scope()
+	if s.Goroutine != "" {
+		return genericParam.ReplaceAllString(s.Goroutine, "")
+	}
+	return genericParam.ReplaceAllString(s.Symbol, "")
+}
+
+// walkFunction visits the nodes of a function with the given scope.
+func (v *expectedDeadlockVisitor) walkFunction(s *functionScope, nodes ...ast.Node) {
+	s.literals, s.gowraps = make(map[int]int), make(map[int]int)
+	v.Scopes = append(v.Scopes, s)
+	for _, node := range nodes {
+		ast.Walk(v, node)
+	}
+	v.Scopes = v.Scopes[:len(v.Scopes)-1]
+}
+
+// literal resolves the symbol name of a function literal in the innermost
+// enclosing function.
+func (v *expectedDeadlockVisitor) literal(lit *ast.FuncLit) string {
+	s, pos := v.scope(), v.FileSet.Position(lit.Pos())
+	i := s.literals[pos.Line]
+	s.literals[pos.Line]++
+	return v.Symbols.Literal(s.Symbol, pos.Filename, pos.Line, i)
+}
+
+// goroutine resolves the symbol name of the function started by a go
+// statement in the innermost enclosing function, and visits the call.
+func (v *expectedDeadlockVisitor) goroutine(n *ast.GoStmt) string {
+	s, pos := v.scope(), v.FileSet.Position(n.Pos())
+	i := s.gowraps[pos.Line]
+	s.gowraps[pos.Line]++
+	wrapper := v.Symbols.GoWrapper(s.Symbol, pos.Filename, pos.Line, i)
+
+	for _, arg := range n.Call.Args {
+		ast.Walk(v, arg)
 	}
 
-	addExpectedDeadlock := func() {
-		comments, ok := v.CommentMap[node]
-		if !ok {
-			return
+	fun := ast.Unparen(n.Call.Fun)
+	switch fun := fun.(type) {
+	case *ast.FuncLit:
+		lit := &functionScope{Symbol: v.literal(fun), Goroutine: wrapper}
+		if lit.Goroutine == "" {
+			lit.Goroutine = lit.Symbol
 		}
-		for _, cg := range comments {
-			for _, line := range strings.Split(cg.Text(), "\n") {
-				if !strings.Contains(line, "deadlocks:") {
-					continue
-				}
-				expression := strings.TrimSpace(strings.TrimPrefix(line, "deadlocks:"))
-				dl := ExpectedDeadlock{
-					Position:     v.FileSet.Position(node.Pos()),
-					FunctionName: v.GetFunctionName(),
-				}
-				if expression != "" {
-					if expr, err := parser.ParseExpr(expression); err != nil {
-						fmt.Printf("Failed to parse expression %s: %v\n", expression, err)
-					} else {
-						dl.Expression = expr
-					}
+		v.walkFunction(lit, fun.Type, fun.Body)
+		return lit.Goroutine
+	case *ast.Ident:
+		ast.Walk(v, fun)
+		if wrapper != "" {
+			return wrapper
+		}
+		// Without a wrapper, the goroutine starts at the callee.
+		if fun.Obj != nil {
+			if decl, ok := fun.Obj.Decl.(*ast.FuncDecl); ok {
+				pos := v.FileSet.Position(decl.Pos())
+				if sym := v.Symbols.Func(decl.Name.Name, pos.Filename, pos.Line); sym != "" {
+					return sym
 				}
-				v.Deadlocks = append(v.Deadlocks, dl)
+				return v.qualifier() + "." + decl.Name.Name
 			}
 		}
+	default:
+		ast.Walk(v, fun)
 	}
+	return wrapper
+}
 
-	switch n := node.(type) {
-	case *ast.GoStmt:
-		switch {
-		case isFunctionLiteral(n.Call.Fun) &&
-			IsCallWithParameters(n.Call):
-			v.FunctionLiteralNesting = v.FunctionLiteralNesting.PushGowrap(n)
-			ast.Walk(v, n.Call.Fun)
-			v.FunctionLiteralNesting = v.FunctionLiteralNesting.PopGowrap()
-			for _, arg := range n.Call.Args {
-				ast.Walk(v, arg)
+// addExpectedDeadlocks adds the deadlock annotations attached to the node,
+// expected at the given function.
+//
+// Annotations guarded by a configuration, e.g., `deadlocks[GOMAXPROCS=1]: 0`,
+// are only added if the configuration of the run satisfies the guard, in which
+// case they replace the unguarded annotations of the node.
+func (v *expectedDeadlockVisitor) addExpectedDeadlocks(node ast.Node, function string) {
+	var guarded, unguarded []ExpectedDeadlock
+	for _, cg := range v.CommentMap[node] {
+		for _, line := range strings.Split(cg.Text(), "\n") {
+			m := annotation.FindStringSubmatch(strings.TrimSpace(line))
+			if m == nil {
+				continue
 			}
-			return nil
-		case isBuiltinFunction(n.Call.Fun),
-			!isFunctionLiteral(n.Call) &&
-				IsCallWithParameters(n.Call):
-			ast.Walk(v, n.Call.Fun)
-			for _, arg := range n.Call.Args {
-				ast.Walk(v, arg)
+			guard, expression := m[1], strings.TrimSpace(m[2])
+			expression, waitReason, _ := strings.Cut(expression, " on ")
+			_, goStmt := node.(*ast.GoStmt)
+			dl := ExpectedDeadlock{
+				Position:     v.FileSet.Position(node.Pos()),
+				FunctionName: genericParam.ReplaceAllString(function, ""),
+				GoStatement:  goStmt,
+				WaitReason:   strings.TrimSpace(waitReason),
+				Test:         v.Test,
 			}
-			// Add a mock function literal token to the nesting to use when adding a deadlock annotation
-			// for a builtin function.
-			// Current builtin functions do not have deadlocking behaviour.
-			v.FunctionLiteralNesting = append(v.FunctionLiteralNesting.PushGowrap(n), &funcLiteral{})
-			addExpectedDeadlock()
-			v.FunctionLiteralNesting = v.FunctionLiteralNesting[:len(v.FunctionLiteralNesting)-1].PopGowrap()
-			return nil
-		}
-	case *ast.DeferStmt:
-		switch {
-		case isFunctionLiteral(n.Call.Fun) &&
-			IsCallWithParameters(n.Call):
-			v.FunctionLiteralNesting = v.FunctionLiteralNesting.PushGowrap(n)
-			ast.Walk(v, n.Call.Fun)
-			v.FunctionLiteralNesting = v.FunctionLiteralNesting.PopGowrap()
-			for _, arg := range n.Call.Args {
-				ast.Walk(v, arg)
+			if expression != "" {
+				if expr, err := parser.ParseExpr(expression); err != nil {
+					fmt.Printf("Failed to parse expression %s: %v\n", expression, err)
+				} else {
+					dl.Expression = expr
+				}
 			}
-			return nil
-		case isBuiltinFunction(n.Call.Fun),
-			!isFunctionLiteral(n.Call) &&
-				IsCallWithParameters(n.Call):
-			ast.Walk(v, n.Call.Fun)
-			for _, arg := range n.Call.Args {
-				ast.Walk(v, arg)
+			if guard == "" {
+				unguarded = append(unguarded, dl)
+				continue
+			}
+			if ok, err := v.Config.Satisfies(guard); err != nil {
+				fmt.Printf("Failed to parse configuration guard %s: %v\n", guard, err)
+			} else if ok {
+				guarded = append(guarded, dl)
 			}
-			addExpectedDeadlock()
-			v.FunctionLiteralNesting = v.FunctionLiteralNesting.PushGowrap(n)
-			v.FunctionLiteralNesting = v.FunctionLiteralNesting.PopGowrap()
-			return nil
 		}
+	}
+	if len(guarded) > 0 {
+		v.Deadlocks = append(v.Deadlocks, guarded...)
+	} else {
+		v.Deadlocks = append(v.Deadlocks, unguarded...)
+	}
+}
+
+// Visit allows expectedDeadlockVisitor to satisfy the ast.Visitor interface.
+func (v *expectedDeadlockVisitor) Visit(node ast.Node) ast.Visitor {
+	if node == nil {
+		return nil
+	}
+
+	switch n := node.(type) {
+	case *ast.File:
+		// Symbols of test packages are qualified by the package path.
+		v.Qualifier = v.Symbols.Package(v.FileSet.Position(n.Pos()).Filename)
+		v.Scopes = nil
+	case *ast.GoStmt:
+		// Annotations at go statements refer to the started goroutine.
+		v.addExpectedDeadlocks(n, v.goroutine(n))
+		return nil
 	case *ast.FuncDecl:
-		v.FunctionName = n.Name.Name
-		v.Receiver = n.Recv
-		functionScope(n.Body)
-		v.FunctionName = ""
+		name := strings.TrimPrefix(ReceiverToString(n.Recv)+"."+n.Name.Name, ".")
+		pos := v.FileSet.Position(n.Pos())
+		s := &functionScope{Symbol: v.Symbols.Func(name, pos.Filename, pos.Line)}
+		if s.Symbol == "" {
+			// The function is not in the binary, but it is still
+			// named the same in traces.
+			s.Symbol = v.qualifier() + "." + name
+		}
+		if n.Recv == nil && strings.HasPrefix(n.Name.Name, "Test") && strings.HasSuffix(pos.Filename, "_test.go") {
+			v.Test = n.Name.Name
+			defer func() { v.Test = "" }()
+		}
+		v.walkFunction(s, n.Body)
 		return nil
 	case *ast.FuncLit:
-		functionScope(n.Type, n.Body)
-		v.FunctionLiteralNesting.IncFun()
+		v.walkFunction(&functionScope{Symbol: v.literal(n)}, n.Type, n.Body)
 		return nil
 	}
-	addExpectedDeadlock()
+	v.addExpectedDeadlocks(node, v.GetFunctionName())
 
 	return v
 }
 
 // getDeadlockExpectations constructs a mapping of deadlock expectations for
 // the given target. The target is the path of the test case package.
-func getDeadlockExpectations(target string, c Config) (ExpectedDeadlocks, error) {
+// Annotations are mapped to the functions of the compiled target through
+// its symbol table, which may be nil if the target failed to compile.
+func getDeadlockExpectations(target string, c Config, symbols *SymbolTable) (ExpectedDeadlocks, error) {
 	fset := token.NewFileSet()
 	pkgs, err := parser.ParseDir(fset, target, nil, parser.ParseComments)
 	if err != nil {
@@ -205,9 +327,11 @@ func getDeadlockExpectations(target string, c Config) (ExpectedDeadlocks, error)
 		}
 
 		vis := &expectedDeadlockVisitor{
-			FileSet:    fset,
-			Package:    p,
-			CommentMap: ast.NewCommentMap(fset, p, comments),
+			FileSet:           fset,
+			Package:           p,
+			CommentMap:        ast.NewCommentMap(fset, p, comments),
+			ExpectedDeadlocks: ExpectedDeadlocks{Config: c},
+			Symbols:           symbols,
 		}
 		ast.Walk(vis, p)
 		expectedDeadlocks.Deadlocks = append(expectedDeadlocks.Deadlocks, vis.Deadlocks...)
@@ -2,6 +2,8 @@ package main
 
 import (
 	"go/ast"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -12,6 +14,11 @@ func TestExpectedDeadlockString(t *testing.T) {
 		FunctionName: "foo",
 		Expression:   &ast.Ident{Name: "x"},
 	}.String())
+	require.Equal(t, "Function: foo; Validation expression: x on chan send", ExpectedDeadlock{
+		FunctionName: "foo",
+		Expression:   &ast.Ident{Name: "x"},
+		WaitReason:   "chan send",
+	}.String())
 }
 
 func TestExpectedDeadlocksString(t *testing.T) {
@@ -38,12 +45,72 @@ func TestGetFunctionName(t *testing.T) {
 
 	require.Equal(t, "foo.init", vis.GetFunctionName())
 
-	vis.FunctionName = "bar"
-	require.Equal(t, "foo.bar", vis.GetFunctionName())
+	vis.Scopes = append(vis.Scopes, &functionScope{Symbol: "foo.(*bar[go.shape.int]).baz.func1"})
+	require.Equal(t, "foo.(*bar).baz.func1", vis.GetFunctionName())
+
+	vis.Scopes[len(vis.Scopes)-1].Goroutine = "foo.bar.gowrap1"
+	require.Equal(t, "foo.bar.gowrap1", vis.GetFunctionName())
 }
 
 func TestGetDeadlockExpectations(t *testing.T) {
-	exp, err := getDeadlockExpectations("testdata/deadlock_expectations.json", Config{})
+	exp, err := getDeadlockExpectations("testdata/deadlock_expectations.json", Config{}, nil)
 	require.Zero(t, exp)
 	require.Error(t, err)
 }
+
+func TestGetDeadlockExpectationsWaitReason(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(`package main
+
+import "sync"
+
+func main() {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		wg.Wait() // deadlocks: x > 0 on sync.WaitGroup.Wait
+	}()
+	go func() {
+		select {} // deadlocks: 1
+	}()
+}
+`), 0o644))
+
+	exp, err := getDeadlockExpectations(dir, Config{}, nil)
+	require.NoError(t, err)
+	require.Len(t, exp.Deadlocks, 2)
+	require.Equal(t, "x > 0", AstString(exp.Deadlocks[0].Expression))
+	require.Equal(t, "sync.WaitGroup.Wait", exp.Deadlocks[0].WaitReason)
+	require.Equal(t, "1", AstString(exp.Deadlocks[1].Expression))
+	require.Empty(t, exp.Deadlocks[1].WaitReason)
+}
+
+func TestGetDeadlockExpectationsGuards(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(`package main
+
+func main() {
+	go func() {
+		// deadlocks[GOMAXPROCS=1]: 0
+		// deadlocks[GOMAXPROCS=2,gcdetectdeadlocks=1]: 1
+		// deadlocks: x > 0
+		select {}
+	}()
+}
+`), 0o644))
+
+	for _, test := range []struct {
+		Config
+		want string
+	}{
+		{Config{maxProcs1, deadlockDetectionCollect}, "0"},
+		{Config{maxProcs2, deadlockDetectionCollect}, "1"},
+		{Config{maxProcs2, deadlockDetectionMonitor}, "x > 0"},
+		{Config{maxProcs4, deadlockDetectionCollect}, "x > 0"},
+	} {
+		exp, err := getDeadlockExpectations(dir, test.Config, nil)
+		require.NoError(t, err)
+		require.Len(t, exp.Deadlocks, 1, test.Config.String())
+		require.Equal(t, test.want, AstString(exp.Deadlocks[0].Expression), test.Config.String())
+	}
+}
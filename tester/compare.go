@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Compare produces a report of the changes in detection rates from the base
+// report to this one, e.g., between two builds of Golf. Results of different
+// build modes are compared separately.
+func (r *Report) Compare(base *Report) string {
+	modes := r.BuildModes()
+	for _, mode := range base.BuildModes() {
+		if !slices.Contains(modes, mode) {
+			modes = append(modes, mode)
+		}
+	}
+	slices.Sort(modes)
+	if len(modes) <= 1 {
+		return r.compare(base)
+	}
+
+	tables := make([]string, 0, len(modes))
+	for _, mode := range modes {
+		tables = append(tables, "Build mode: "+string(mode)+"\n"+r.ForBuildMode(mode).compare(base.ForBuildMode(mode)))
+	}
+	return strings.Join(tables, "\n\n")
+}
+
+// compare produces a report of the changes in detection rates from the base
+// report, regardless of build modes.
+//
+// The detection rates of every annotation, across GOMAXPROCS values, are
+// compared with Fisher's exact test. Their p-values are adjusted for the
+// number of annotations with the Holm–Bonferroni method, and changes are
+// significant if the adjusted p-value is below the significance level.
+// Only the annotations whose detection rates changed are listed, followed by
+// the aggregated detection rates of the annotations found in both reports.
+func (r *Report) compare(base *Report) string {
+	_, baseEntries := base.tabEntries()
+	_, entries := r.tabEntries()
+
+	type comparison struct {
+		goro                    string
+		baseFound, baseExpected int
+		found, expected         int
+		pvalue                  float64
+	}
+
+	var (
+		comparisons []comparison
+		pvalues     []float64
+		aggregated  comparison
+		unchanged   int
+		missing     []string
+	)
+	for pos, entry := range entries {
+		baseEntry, ok := baseEntries[pos]
+		if !ok {
+			missing = append(missing, pos)
+			continue
+		}
+		c := comparison{goro: pos}
+		c.baseFound, c.baseExpected = baseEntry.found()
+		c.found, c.expected = entry.found()
+		c.pvalue = fisherExactTest(c.baseFound, c.baseExpected, c.found, c.expected)
+		aggregated.baseFound += c.baseFound
+		aggregated.baseExpected += c.baseExpected
+		aggregated.found += c.found
+		aggregated.expected += c.expected
+
+		// All annotations are part of the family of tests, including the unchanged ones.
+		pvalues = append(pvalues, c.pvalue)
+		comparisons = append(comparisons, c)
+	}
+	for pos := range baseEntries {
+		if _, ok := entries[pos]; !ok {
+			missing = append(missing, pos)
+		}
+	}
+
+	for i, pvalue := range holmAdjust(pvalues) {
+		comparisons[i].pvalue = pvalue
+	}
+	changed := make([]comparison, 0, len(comparisons))
+	for _, c := range comparisons {
+		// Compare the detection rates exactly, by cross-multiplication.
+		if c.baseFound*c.expected == c.found*c.baseExpected {
+			unchanged++
+			continue
+		}
+		changed = append(changed, c)
+	}
+	slices.SortFunc(changed, func(c1, c2 comparison) int {
+		return strings.Compare(c1.goro, c2.goro)
+	})
+
+	rate := func(found, expected int) string {
+		return fmt.Sprintf("%d/%d (%.2f%%)", found, expected, float64(found)/float64(expected)*100)
+	}
+	row := func(name string, c comparison) string {
+		significant := ""
+		if c.pvalue < significanceLevel {
+			significant = "*"
+		}
+		change := (float64(c.found)/float64(c.expected) - float64(c.baseFound)/float64(c.baseExpected)) * 100
+		return strings.Join([]string{
+			name,
+			rate(c.baseFound, c.baseExpected),
+			rate(c.found, c.expected),
+			strconv.FormatFloat(change, 'f', 2, 64) + "%",
+			strconv.FormatFloat(c.pvalue, 'f', 4, 64),
+			significant,
+		}, "\t")
+	}
+
+	content := make([]string, 1, len(changed)+4)
+	content[0] = "Benchmark\tBase\tNew\tChange\tp-value\tSignificant"
+	for _, c := range changed {
+		prettyTarget := strings.TrimPrefix(c.goro, "tests/deadlock/")
+		prettyTarget = strings.TrimPrefix(prettyTarget, "gobench/")
+		prettyTarget = strings.Replace(prettyTarget, "blocking/", "", 1)
+		content = append(content, row(prettyTarget, c))
+	}
+	content = append(content, fmt.Sprintf("Unchanged %d go instruction", unchanged))
+	if aggregated.baseExpected > 0 && aggregated.expected > 0 {
+		// The aggregated detection rates are a single test.
+		aggregated.pvalue = fisherExactTest(aggregated.baseFound, aggregated.baseExpected, aggregated.found, aggregated.expected)
+		content = append(content, row("Aggregated", aggregated))
+	}
+	if len(missing) > 0 {
+		slices.Sort(missing)
+		content = append(content, "Not in both results: "+strings.Join(missing, ", "))
+	}
+
+	return strings.Join(content, "\n")
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportCompare(t *testing.T) {
+	// report has a run of each of the targets per repeat round, which
+	// finds the expected deadlocks of the targets found[target] times.
+	report := func(found map[string]int, repeats int) *Report {
+		r := &Report{Results: map[string]*TargetReport{}}
+		for target, n := range found {
+			for i := 0; i < repeats; i++ {
+				tr := &TargetReport{
+					Config: Config{maxProcs1, deadlockDetectionCollect},
+					ExpectedDeadlocks: ExpectedDeadlocks{
+						Target: target,
+						Deadlocks: []ExpectedDeadlock{{
+							Position:   token.Position{Filename: target + "/main.go", Line: 10},
+							Expression: &ast.Ident{Name: "true"},
+						}},
+					},
+					Diff: &DeadlockDifferential{},
+				}
+				if i >= n {
+					tr.Diff.Mismatches = []DeadlockMismatch{{ExpectedDeadlock: tr.Deadlocks[0]}}
+				}
+				r.Results[target+"-"+strconv.Itoa(i)] = tr
+			}
+		}
+		return r
+	}
+
+	base := report(map[string]int{"foo": 20, "bar": 19, "baz": 20, "qux": 20}, 20)
+	r := report(map[string]int{"foo": 0, "bar": 18, "baz": 20, "quux": 20}, 20)
+
+	lines := strings.Split(r.Compare(base), "\n")
+	require.Equal(t, []string{
+		"Benchmark\tBase\tNew\tChange\tp-value\tSignificant",
+		"bar/main.go:10\t19/20 (95.00%)\t18/20 (90.00%)\t-5.00%\t1.0000\t",
+		"foo/main.go:10\t20/20 (100.00%)\t0/20 (0.00%)\t-100.00%\t0.0000\t*",
+		"Unchanged 1 go instruction",
+		"Aggregated\t59/60 (98.33%)\t38/60 (63.33%)\t-35.00%\t0.0000\t*",
+		"Not in both results: quux/main.go:10, qux/main.go:10",
+	}, lines)
+}
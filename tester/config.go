@@ -1,42 +1,19 @@
 package main
 
 import (
+	"fmt"
 	"strings"
 )
 
 // Config is a configuration of the program.
 type Config []configvalue
 
-const (
-	// Configuration values
-	VERBOSITY = iota
-	GOLFFLAG
-	PROCS
-)
-
-var (
-	// defaultvalues is the default configuration for the program
-	defaultvalues = []Config{
-		VERBOSITY: {
-			gcddtraceOff,
-			// gcddtraceOn,
-			// gcddtraceTarget,
-		},
-		GOLFFLAG: {
-			deadlockDetectionOff,
-			deadlockDetectionCollect,
-			// deadlockDetectionMonitor,
-		},
-		PROCS: {
-			maxProcs1,
-			maxProcs2,
-			maxProcs4,
-			maxProcs10,
-		}}
-)
-
 func (c Config) String() string {
-	return strings.Join(c.Flags(), " ")
+	flags := c.Flags()
+	if mode := c.BuildMode(); mode != buildPlain {
+		flags = append(flags, mode.String())
+	}
+	return strings.Join(flags, " ")
 }
 
 func (c Config) Name() string {
@@ -61,6 +38,8 @@ func (c Config) Flags() []string {
 	var envVars, gcFlags []string
 	for _, v := range c {
 		switch v := v.(type) {
+		case buildflag:
+			// Build flags are not part of the environment.
 		case gcflag:
 			gcFlags = append(gcFlags, v.String())
 		case configvalue:
@@ -78,6 +57,58 @@ func (c Config) Flags() []string {
 	return append(envVars, godebug)
 }
 
+// BuildMode returns the mode targets are built in under the configuration.
+func (c Config) BuildMode() buildMode {
+	for _, v := range c {
+		if v, ok := v.(buildMode); ok {
+			return v
+		}
+	}
+	return buildPlain
+}
+
+// BuildFlags returns the flags passed to the Go command when compiling
+// targets for the configuration.
+func (c Config) BuildFlags() []string {
+	var flags []string
+	for _, v := range c {
+		if v, ok := v.(buildflag); ok {
+			flags = append(flags, v.BuildFlags()...)
+		}
+	}
+	return flags
+}
+
+// Value returns the value of the given configuration key, e.g., "1" for
+// "GOMAXPROCS" in a configuration with GOMAXPROCS=1.
+func (c Config) Value(key string) (string, bool) {
+	for _, v := range c {
+		if k, value, ok := strings.Cut(v.String(), "="); ok && k == key {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// Satisfies returns true if the configuration satisfies the given guard.
+// A guard is a comma-separated list of conditions `KEY=VALUE` or `KEY!=VALUE`,
+// all of which must hold, e.g., "GOMAXPROCS=1,gcdetectdeadlocks!=2".
+// Keys missing from the configuration have no value.
+func (c Config) Satisfies(guard string) (bool, error) {
+	for _, cond := range strings.Split(guard, ",") {
+		key, value, ok := strings.Cut(cond, "=")
+		if !ok {
+			return false, fmt.Errorf("condition %q is not of the form KEY=VALUE or KEY!=VALUE", cond)
+		}
+		key, negated := strings.CutSuffix(strings.TrimSpace(key), "!")
+		actual, _ := c.Value(key)
+		if (actual == strings.TrimSpace(value)) == negated {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
 // HasDeadlockDetection returns true if the configuration has deadlock detection enabled.
 func (c Config) HasDeadlockDetection() bool {
 	for _, v := range c {
@@ -109,18 +140,20 @@ func (c Config) WithToggledDeadlockDetection() Config {
 	return c2
 }
 
-// EmitConfigurations produces configurations that should be run on the given examples.
+// EmitConfigurations produces configurations that should be run on the given examples,
+// i.e., every combination of the values of the dimensions of the matrix.
 func EmitConfigurations() chan Config {
 	ch := make(chan Config, 10)
+	dimensions := matrix.Dimensions()
 	go func() {
 		var constructConfig func([]configvalue, int)
 		constructConfig = func(c []configvalue, i int) {
-			if i == len(defaultvalues) {
+			if i == len(dimensions) {
 				ch <- c
 				return
 			}
 
-			for _, v := range defaultvalues[i] {
+			for _, v := range dimensions[i] {
 				cpy := make([]configvalue, len(c)+1)
 				copy(cpy, append(c, v))
 				constructConfig(cpy, i+1)
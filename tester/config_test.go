@@ -53,9 +53,57 @@ func TestEmitConfigurations(t *testing.T) {
 	}
 
 	expectedConfigs := 1
-	for _, vs := range defaultvalues {
+	for _, vs := range matrix.Dimensions() {
 		expectedConfigs *= len(vs)
 	}
 
 	require.Equal(t, expectedConfigs, yieldedConfigs)
 }
+
+func TestConfigSatisfies(t *testing.T) {
+	c := Config{
+		maxProcs1,
+		deadlockDetectionCollect,
+	}
+
+	v, ok := c.Value("GOMAXPROCS")
+	require.True(t, ok)
+	require.Equal(t, "1", v)
+	_, ok = c.Value("gcddtrace")
+	require.False(t, ok)
+
+	for guard, want := range map[string]bool{
+		"GOMAXPROCS=1":                      true,
+		"GOMAXPROCS=2":                      false,
+		"GOMAXPROCS!=1":                     false,
+		"GOMAXPROCS!=2":                     true,
+		"GOMAXPROCS=1,gcdetectdeadlocks=1":  true,
+		"GOMAXPROCS=1, gcdetectdeadlocks=2": false,
+		"gcddtrace=1":                       false,
+		"gcddtrace!=1":                      true,
+	} {
+		ok, err := c.Satisfies(guard)
+		require.NoError(t, err, guard)
+		require.Equal(t, want, ok, guard)
+	}
+
+	_, err := c.Satisfies("GOMAXPROCS")
+	require.Error(t, err)
+}
+
+func TestConfigBuildMode(t *testing.T) {
+	c := Config{maxProcs1, buildRace}
+	require.Equal(t, buildRace, c.BuildMode())
+	require.Equal(t, []string{"-race"}, c.BuildFlags())
+	require.Equal(t, []string{"GOMAXPROCS=1", "GODEBUG=gctrace=1"}, c.Flags())
+	require.Equal(t, "GOMAXPROCS=1 GODEBUG=gctrace=1 build=race", c.String())
+	require.Equal(t, "GOMAXPROCS-1-build-race", c.Name())
+
+	ok, err := c.Satisfies("build=race")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	c = Config{maxProcs1}
+	require.Equal(t, buildPlain, c.BuildMode())
+	require.Empty(t, c.BuildFlags())
+}
@@ -1,6 +1,11 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
 
 type (
 	// maxProcs is the type to represent the GOMAXPROCS value
@@ -9,6 +14,18 @@ type (
 	deadlockDetection int
 	// gcddtrace is the type to represent the `gcddtrace` flag value
 	gcddtrace int
+	// gcstoptheworld is the type to represent the `gcstoptheworld` flag value
+	gcstoptheworld int
+	// gogc is the type to represent the GOGC value
+	gogc string
+	// gomemlimit is the type to represent the GOMEMLIMIT value
+	gomemlimit string
+	// buildMode is the type to represent the mode targets are built in
+	buildMode string
+	// godebugValue is the type to represent the value of any other GODEBUG key
+	godebugValue struct{ key, value string }
+	// envValue is the type to represent the value of an environment variable
+	envValue struct{ key, value string }
 
 	// configvalue should be implemented by all configuration value types
 	configvalue interface {
@@ -22,10 +39,17 @@ type (
 		String() string
 		isGCFlag()
 	}
+
+	// buildflag must be implemented by all configuration value types
+	// that affect the compilation of targets instead of their execution.
+	buildflag interface {
+		BuildFlags() []string
+		isBuildFlag()
+	}
 )
 
 const (
-	// Values for the maxProcs type: 1, 10
+	// Common values for the maxProcs type. Any positive value is valid.
 	maxProcs1, maxProcs2, maxProcs4, maxProcs10 maxProcs = 1, 2, 4, 10
 
 	// Values for the deadlockDetection type: 0, 1, 2
@@ -33,19 +57,26 @@ const (
 
 	// Values for the gcddtrace type: 0, 1
 	gcddtraceOff, gcddtraceOn, gcddtraceTarget gcddtrace = 0, 1, 2
+
+	// Values for the gcstoptheworld type: 0 (concurrent GC), 1 (STW marking), 2 (STW marking and sweeping)
+	gcstoptheworldOff, gcstoptheworldMark, gcstoptheworldSweep gcstoptheworld = 0, 1, 2
 )
 
+// Values for the buildMode type: plain, with the race detector, and without optimizations or inlining
+const buildPlain, buildRace, buildNoOpt buildMode = "plain", "race", "noopt"
+
+// gomemlimitValue matches the values of GOMEMLIMIT: "off", or a number of bytes, with an optional unit.
+var gomemlimitValue = regexp.MustCompile(`^(off|[0-9]+(B|KiB|MiB|GiB|TiB)?)$`)
+
 func (m maxProcs) String() string {
-	switch m {
-	case maxProcs1, maxProcs2, maxProcs4, maxProcs10:
+	if m > 0 {
 		return fmt.Sprintf("GOMAXPROCS=%v", int(m))
 	}
 	panic(fmt.Sprintf("Unrecognized GOMAXPROCS value: %v", int(m)))
 }
 
 func (m maxProcs) Name() string {
-	switch m {
-	case maxProcs1, maxProcs2, maxProcs4, maxProcs10:
+	if m > 0 {
 		return fmt.Sprintf("GOMAXPROCS-%v", int(m))
 	}
 	panic(fmt.Sprintf("Unrecognized GOMAXPROCS value: %v", int(m)))
@@ -94,3 +125,126 @@ func (m gcddtrace) Name() string {
 
 func (m gcddtrace) isGCFlag()      {}
 func (m gcddtrace) isConfigValue() {}
+
+func (m gcstoptheworld) String() string {
+	switch m {
+	case gcstoptheworldOff, gcstoptheworldMark, gcstoptheworldSweep:
+		return fmt.Sprintf("gcstoptheworld=%v", int(m))
+	}
+	panic(fmt.Sprintf("Unrecognized gcstoptheworld value: %v", int(m)))
+}
+
+func (m gcstoptheworld) Name() string {
+	switch m {
+	case gcstoptheworldOff, gcstoptheworldMark, gcstoptheworldSweep:
+		return fmt.Sprintf("gcstoptheworld-%v", int(m))
+	}
+	panic(fmt.Sprintf("Unrecognized gcstoptheworld value: %v", int(m)))
+}
+
+func (m gcstoptheworld) isGCFlag()      {}
+func (m gcstoptheworld) isConfigValue() {}
+
+// valid returns true for "off" and non-negative percentages.
+func (m gogc) valid() bool {
+	if m == "off" {
+		return true
+	}
+	v, err := strconv.Atoi(string(m))
+	return err == nil && v >= 0
+}
+
+func (m gogc) String() string {
+	if m.valid() {
+		return "GOGC=" + string(m)
+	}
+	panic(fmt.Sprintf("Unrecognized GOGC value: %v", string(m)))
+}
+
+func (m gogc) Name() string {
+	if m.valid() {
+		return "GOGC-" + string(m)
+	}
+	panic(fmt.Sprintf("Unrecognized GOGC value: %v", string(m)))
+}
+
+func (m gogc) isConfigValue() {}
+
+// valid returns true for "off" and limits such as "512MiB".
+func (m gomemlimit) valid() bool {
+	return gomemlimitValue.MatchString(string(m))
+}
+
+func (m gomemlimit) String() string {
+	if m.valid() {
+		return "GOMEMLIMIT=" + string(m)
+	}
+	panic(fmt.Sprintf("Unrecognized GOMEMLIMIT value: %v", string(m)))
+}
+
+func (m gomemlimit) Name() string {
+	if m.valid() {
+		return "GOMEMLIMIT-" + string(m)
+	}
+	panic(fmt.Sprintf("Unrecognized GOMEMLIMIT value: %v", string(m)))
+}
+
+func (m gomemlimit) isConfigValue() {}
+
+func (m buildMode) String() string {
+	switch m {
+	case buildPlain, buildRace, buildNoOpt:
+		return "build=" + string(m)
+	}
+	panic(fmt.Sprintf("Unrecognized build mode: %v", string(m)))
+}
+
+func (m buildMode) Name() string {
+	switch m {
+	case buildPlain, buildRace, buildNoOpt:
+		return "build-" + string(m)
+	}
+	panic(fmt.Sprintf("Unrecognized build mode: %v", string(m)))
+}
+
+// BuildFlags returns the flags passed to the Go command to build in the mode.
+func (m buildMode) BuildFlags() []string {
+	switch m {
+	case buildPlain:
+		return nil
+	case buildRace:
+		return []string{"-race"}
+	case buildNoOpt:
+		return []string{"-gcflags=-N -l"}
+	}
+	panic(fmt.Sprintf("Unrecognized build mode: %v", string(m)))
+}
+
+func (m buildMode) isBuildFlag()   {}
+func (m buildMode) isConfigValue() {}
+
+func (m godebugValue) String() string {
+	return m.key + "=" + m.value
+}
+
+func (m godebugValue) Name() string {
+	return m.key + "-" + nameValue(m.value)
+}
+
+func (m godebugValue) isGCFlag()      {}
+func (m godebugValue) isConfigValue() {}
+
+func (m envValue) String() string {
+	return m.key + "=" + m.value
+}
+
+func (m envValue) Name() string {
+	return m.key + "-" + nameValue(m.value)
+}
+
+func (m envValue) isConfigValue() {}
+
+// nameValue makes a value usable in the name of a trace file.
+func nameValue(v string) string {
+	return strings.NewReplacer("/", "_", " ", "_").Replace(v)
+}
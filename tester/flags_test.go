@@ -59,3 +59,57 @@ func TestFlagDeadlockDetectionDebugTraceName(t *testing.T) {
 		_ = gcddtrace(-1).Name()
 	})
 }
+
+func TestFlagStopTheWorldString(t *testing.T) {
+	gcstoptheworldOff.isGCFlag()
+	gcstoptheworldOff.isConfigValue()
+
+	require.Equal(t, "gcstoptheworld=1", gcstoptheworldMark.String())
+	require.Equal(t, "gcstoptheworld-2", gcstoptheworldSweep.Name())
+	require.PanicsWithValue(t, "Unrecognized gcstoptheworld value: 3", func() {
+		_ = gcstoptheworld(3).String()
+	})
+	require.PanicsWithValue(t, "Unrecognized gcstoptheworld value: 3", func() {
+		_ = gcstoptheworld(3).Name()
+	})
+}
+
+func TestFlagGOGC(t *testing.T) {
+	gogc("off").isConfigValue()
+
+	require.Equal(t, "GOGC=50", gogc("50").String())
+	require.Equal(t, "GOGC-off", gogc("off").Name())
+	require.PanicsWithValue(t, "Unrecognized GOGC value: -1", func() {
+		_ = gogc("-1").String()
+	})
+	require.PanicsWithValue(t, "Unrecognized GOGC value: lazy", func() {
+		_ = gogc("lazy").Name()
+	})
+}
+
+func TestFlagGOMEMLIMIT(t *testing.T) {
+	gomemlimit("off").isConfigValue()
+
+	require.Equal(t, "GOMEMLIMIT=512MiB", gomemlimit("512MiB").String())
+	require.Equal(t, "GOMEMLIMIT-1073741824", gomemlimit("1073741824").Name())
+	require.PanicsWithValue(t, "Unrecognized GOMEMLIMIT value: 1GB", func() {
+		_ = gomemlimit("1GB").String()
+	})
+	require.PanicsWithValue(t, "Unrecognized GOMEMLIMIT value: ", func() {
+		_ = gomemlimit("").Name()
+	})
+}
+
+func TestFlagBuildMode(t *testing.T) {
+	buildPlain.isBuildFlag()
+	buildPlain.isConfigValue()
+
+	require.Equal(t, "build=race", buildRace.String())
+	require.Equal(t, "build-noopt", buildNoOpt.Name())
+	require.Empty(t, buildPlain.BuildFlags())
+	require.Equal(t, []string{"-race"}, buildRace.BuildFlags())
+	require.Equal(t, []string{"-gcflags=-N -l"}, buildNoOpt.BuildFlags())
+	require.PanicsWithValue(t, "Unrecognized build mode: msan", func() {
+		_ = buildMode("msan").BuildFlags()
+	})
+}
@@ -0,0 +1,402 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Report formats, as given by the `-format` flag.
+const (
+	formatText  = "text"
+	formatJSON  = "json"
+	formatJUnit = "junit"
+	formatHTML  = "html"
+)
+
+// Format produces the report in the given format: JSON, JUnit XML or HTML.
+func (r *Report) Format(format string) ([]byte, error) {
+	switch format {
+	case formatJSON:
+		return r.JSON()
+	case formatJUnit:
+		return r.JUnit()
+	case formatHTML:
+		return r.HTML()
+	}
+	return nil, fmt.Errorf("unrecognized report format: %q", format)
+}
+
+// sortedResults returns the reports of the given map, sorted by trace file.
+func sortedResults(results map[string]*TargetReport) []*TargetReport {
+	reports := make([]*TargetReport, 0, len(results))
+	for _, report := range results {
+		reports = append(reports, report)
+	}
+	slices.SortFunc(reports, func(r1, r2 *TargetReport) int {
+		return strings.Compare(r1.TraceFile, r2.TraceFile)
+	})
+	return reports
+}
+
+// Exceptions lists the exceptions raised while running the target,
+// followed by the fatal error or panic in its trace, if any.
+func (r *TargetReport) Exceptions() []string {
+	var exceptions []string
+	if r.Exception != nil {
+		exceptions = strings.Split(r.Exception.Error(), "\n")
+	}
+	if msg, ok := r.TraceHasExceptions(); ok {
+		exceptions = append(exceptions, msg)
+	}
+	return exceptions
+}
+
+// ReportedMismatches returns the mismatches reported for the run, i.e.,
+// unexpected deadlocks and deadlocks blocked for the wrong reason.
+// Runs that miss expected deadlocks only lower the detection rate.
+func (r *TargetReport) ReportedMismatches() []DeadlockMismatch {
+	if r.Diff == nil {
+		r.CompareWithTrace()
+	}
+	var mismatches []DeadlockMismatch
+	for _, mismatch := range r.Diff.Mismatches {
+		if mismatch.Unexpected || mismatch.WrongWaitReason {
+			mismatches = append(mismatches, mismatch)
+		}
+	}
+	return mismatches
+}
+
+type (
+	// jsonReport is the JSON representation of a report.
+	jsonReport struct {
+		Results []jsonTargetReport `json:"results"`
+	}
+
+	// jsonTargetReport is the JSON representation of a target execution.
+	jsonTargetReport struct {
+		Target string `json:"target"`
+		// Test is the Test function run, for test packages.
+		Test string `json:"test,omitempty"`
+		// Config is the name of the configuration, and Settings its values by key.
+		Config    string            `json:"config"`
+		Settings  map[string]string `json:"settings"`
+		Repeat    int               `json:"repeat"`
+		TraceFile string            `json:"traceFile"`
+		// Validated is false for runs of targets without deadlock annotations.
+		Validated bool `json:"validated"`
+
+		ExpectedDeadlocks      []jsonExpectedDeadlock `json:"expectedDeadlocks"`
+		Deadlocks              []jsonDeadlock         `json:"deadlocks"`
+		Mismatches             []jsonMismatch         `json:"mismatches"`
+		CorrectDeadlockFound   int                    `json:"correctDeadlockFound"`
+		CorrectNoDeadlockFound int                    `json:"correctNoDeadlockFound"`
+		Exceptions             []string               `json:"exceptions"`
+		Comments               []string               `json:"comments"`
+		Races                  int                    `json:"races"`
+		Goroutines             int                    `json:"goroutines"`
+		GC                     *jsonGC                `json:"gc,omitempty"`
+		// Metrics holds the runtime metrics of instrumented targets, if any.
+		Metrics *RuntimeMetrics `json:"runtimeMetrics,omitempty"`
+	}
+
+	// jsonExpectedDeadlock is the JSON representation of a deadlock annotation.
+	jsonExpectedDeadlock struct {
+		File        string `json:"file"`
+		Line        int    `json:"line"`
+		Function    string `json:"function"`
+		GoStatement bool   `json:"goStatement"`
+		Expression  string `json:"expression"`
+		WaitReason  string `json:"waitReason,omitempty"`
+	}
+
+	// jsonDeadlock is the JSON representation of a deadlock in a trace.
+	jsonDeadlock struct {
+		Goid        int         `json:"goid"`
+		Function    string      `json:"function"`
+		WaitReason  string      `json:"waitReason"`
+		Stack       []jsonFrame `json:"stack"`
+		CreatedBy   *jsonFrame  `json:"createdBy,omitempty"`
+		CreatorGoid int         `json:"creatorGoid,omitempty"`
+	}
+
+	// jsonFrame is the JSON representation of a traceback frame.
+	jsonFrame struct {
+		Function string `json:"function"`
+		File     string `json:"file"`
+		Line     int    `json:"line"`
+	}
+
+	// jsonMismatch is the JSON representation of a deadlock mismatch.
+	jsonMismatch struct {
+		// Kind is "unexpected" for unexpected deadlocks, "waitReason" for
+		// deadlocks blocked for the wrong reason, and "count" otherwise.
+		Kind string `json:"kind"`
+		// Expected is the violated annotation, unless the deadlocks were unexpected.
+		Expected         *jsonExpectedDeadlock `json:"expected,omitempty"`
+		Function         string                `json:"function"`
+		Actual           int                   `json:"actual"`
+		ActualWaitReason string                `json:"actualWaitReason,omitempty"`
+		Message          string                `json:"message"`
+	}
+
+	// jsonGC is the JSON representation of the GC performance of a run.
+	jsonGC struct {
+		Cycles          int           `json:"cycles"`
+		AvgUtilization  float64       `json:"avgUtilization"`
+		AvgMarkCPU      float64       `json:"avgMarkCPU"`
+		FinalHeapSize   int           `json:"finalHeapSize"`
+		FinalStackSize  int           `json:"finalStackSize"`
+		FinalGoroutines int           `json:"finalGoroutines"`
+		Messages        []jsonGCTrace `json:"messages"`
+	}
+
+	// jsonGCTrace is the JSON representation of the GC trace of a cycle.
+	jsonGCTrace struct {
+		Cycle          int     `json:"cycle"`
+		CPUUtilization float64 `json:"cpuUtilization"`
+		TimeUnit       string  `json:"timeUnit"`
+		ClockMarkTime  float64 `json:"clockMarkTime"`
+		ClockTermTime  float64 `json:"clockTermTime"`
+		CPUMarkTime    float64 `json:"cpuMarkTime"`
+		CPUTermTime    float64 `json:"cpuTermTime"`
+		MemUnit        string  `json:"memUnit"`
+		LiveHeap       int     `json:"liveHeap"`
+		StackSize      int     `json:"stackSize"`
+		Processors     int     `json:"processors"`
+	}
+)
+
+// JSON produces the report as JSON, with every target execution.
+func (r *Report) JSON() ([]byte, error) {
+	report := jsonReport{Results: []jsonTargetReport{}}
+	for _, tr := range sortedResults(r.Results) {
+		report.Results = append(report.Results, tr.json(true))
+	}
+	for _, tr := range sortedResults(r.Unvalidated) {
+		report.Results = append(report.Results, tr.json(false))
+	}
+	return json.MarshalIndent(report, "", "  ")
+}
+
+func (r *TargetReport) json(validated bool) jsonTargetReport {
+	if r.Diff == nil {
+		r.CompareWithTrace()
+	}
+
+	jr := jsonTargetReport{
+		Target:                 r.ExpectedDeadlocks.Target,
+		Test:                   r.Name,
+		Config:                 r.Config.Name(),
+		Settings:               make(map[string]string),
+		Repeat:                 r.Repeat,
+		TraceFile:              r.TraceFile,
+		Validated:              validated,
+		ExpectedDeadlocks:      []jsonExpectedDeadlock{},
+		Deadlocks:              []jsonDeadlock{},
+		Mismatches:             []jsonMismatch{},
+		CorrectDeadlockFound:   r.Diff.CorrectDeadlockFound,
+		CorrectNoDeadlockFound: r.Diff.CorrectNoDeadlockFound,
+		Exceptions:             append([]string{}, r.Exceptions()...),
+		Comments:               append([]string{}, r.Comments()...),
+		Races:                  r.Trace.Races,
+		Goroutines:             r.Trace.NumGoroutines,
+		Metrics:                r.Metrics,
+	}
+	for _, v := range r.Config {
+		if key, value, ok := strings.Cut(v.String(), "="); ok {
+			jr.Settings[key] = value
+		}
+	}
+	for _, dl := range r.ExpectedDeadlocks.Deadlocks {
+		jr.ExpectedDeadlocks = append(jr.ExpectedDeadlocks, jsonExpected(dl))
+	}
+	for _, dl := range r.Trace.Deadlocks {
+		jdl := jsonDeadlock{
+			Goid:        dl.Goid,
+			Function:    dl.FunctionName,
+			WaitReason:  dl.WaitReason,
+			Stack:       []jsonFrame{},
+			CreatorGoid: dl.CreatorGoid,
+		}
+		for _, frame := range dl.Stack {
+			jdl.Stack = append(jdl.Stack, jsonFrame(frame))
+		}
+		if dl.CreatedBy != (TraceFrame{}) {
+			createdBy := jsonFrame(dl.CreatedBy)
+			jdl.CreatedBy = &createdBy
+		}
+		jr.Deadlocks = append(jr.Deadlocks, jdl)
+	}
+	for _, mismatch := range r.Diff.Mismatches {
+		jm := jsonMismatch{
+			Kind:     "count",
+			Function: mismatch.ExpectedDeadlock.FunctionName,
+			Actual:   mismatch.TraceDeadlock.Count,
+			Message:  mismatch.String(),
+		}
+		switch {
+		case mismatch.Unexpected:
+			jm.Kind = "unexpected"
+			jm.Function = mismatch.TraceDeadlock.FunctionName
+		case mismatch.WrongWaitReason:
+			jm.Kind = "waitReason"
+			jm.ActualWaitReason = mismatch.TraceDeadlock.WaitReason
+		}
+		if !mismatch.Unexpected {
+			expected := jsonExpected(mismatch.ExpectedDeadlock)
+			jm.Expected = &expected
+		}
+		jr.Mismatches = append(jr.Mismatches, jm)
+	}
+
+	if len(r.Trace.GCMessages) > 0 {
+		perf := r.Trace.GetGCPerf()
+		jr.GC = &jsonGC{
+			Cycles:          perf.gcCycles,
+			AvgUtilization:  perf.avgUtilization,
+			AvgMarkCPU:      perf.avgMarkCPU,
+			FinalHeapSize:   perf.finalHeapSize,
+			FinalStackSize:  perf.finalStackSize,
+			FinalGoroutines: perf.finalGoroutines,
+		}
+		for _, gc := range r.Trace.GCMessages {
+			jr.GC.Messages = append(jr.GC.Messages, jsonGCTrace{
+				Cycle:          gc.cycle,
+				CPUUtilization: gc.cpuUtilization,
+				TimeUnit:       gc.timeUnit,
+				ClockMarkTime:  gc.clockMarkTime,
+				ClockTermTime:  gc.clockTermTime,
+				CPUMarkTime:    gc.cpuMarkTime,
+				CPUTermTime:    gc.cpuTermTime,
+				MemUnit:        gc.memUnit,
+				LiveHeap:       gc.liveHeap,
+				StackSize:      gc.stackSize,
+				Processors:     gc.processors,
+			})
+		}
+	}
+	return jr
+}
+
+func jsonExpected(dl ExpectedDeadlock) jsonExpectedDeadlock {
+	return jsonExpectedDeadlock{
+		File:        dl.Filename,
+		Line:        dl.Line,
+		Function:    dl.FunctionName,
+		GoStatement: dl.GoStatement,
+		Expression:  AstString(dl.Expression),
+		WaitReason:  dl.WaitReason,
+	}
+}
+
+type (
+	// junitTestSuites is the root of a JUnit XML report.
+	junitTestSuites struct {
+		XMLName  xml.Name         `xml:"testsuites"`
+		Tests    int              `xml:"tests,attr"`
+		Failures int              `xml:"failures,attr"`
+		Errors   int              `xml:"errors,attr"`
+		Skipped  int              `xml:"skipped,attr"`
+		Suites   []junitTestSuite `xml:"testsuite"`
+	}
+
+	// junitTestSuite holds the executions of a target.
+	junitTestSuite struct {
+		Name     string          `xml:"name,attr"`
+		Tests    int             `xml:"tests,attr"`
+		Failures int             `xml:"failures,attr"`
+		Errors   int             `xml:"errors,attr"`
+		Skipped  int             `xml:"skipped,attr"`
+		Cases    []junitTestCase `xml:"testcase"`
+	}
+
+	// junitTestCase is a target execution under a configuration, in a repeat round.
+	junitTestCase struct {
+		Name      string        `xml:"name,attr"`
+		ClassName string        `xml:"classname,attr"`
+		Failure   *junitMessage `xml:"failure,omitempty"`
+		Error     *junitMessage `xml:"error,omitempty"`
+		Skipped   *junitMessage `xml:"skipped,omitempty"`
+		SystemOut string        `xml:"system-out,omitempty"`
+	}
+
+	// junitMessage is the failure, error or reason for skipping of a test case.
+	junitMessage struct {
+		Message string `xml:"message,attr"`
+		Body    string `xml:",chardata"`
+	}
+)
+
+// JUnit produces the report as JUnit XML. Every execution of a target, under a
+// configuration and in a repeat round, is a test case, and the executions
+// of each target form a test suite. Deadlock mismatches and inconsistent
+// annotations are failures, while exceptions are errors. Executions of targets
+// without deadlock annotations are skipped, unless they raised exceptions.
+func (r *Report) JUnit() ([]byte, error) {
+	// suites holds the index of the suite of every target in report.Suites.
+	suites := make(map[string]int)
+	report := junitTestSuites{}
+	addCase := func(tr *TargetReport, validated bool) {
+		i, ok := suites[tr.ExpectedDeadlocks.Target]
+		if !ok {
+			i = len(report.Suites)
+			report.Suites = append(report.Suites, junitTestSuite{Name: tr.ExpectedDeadlocks.Target})
+			suites[tr.ExpectedDeadlocks.Target] = i
+		}
+		suite := &report.Suites[i]
+
+		tc := junitTestCase{
+			Name:      tr.Config.Name() + "#" + strconv.Itoa(tr.Repeat),
+			ClassName: tr.ExpectedDeadlocks.Target,
+			SystemOut: tr.TraceFile,
+		}
+		var failures []string
+		// Deadlocks are only validated if deadlock detection is enabled.
+		if validated && tr.HasDeadlockDetection() {
+			for _, mismatch := range tr.ReportedMismatches() {
+				failures = append(failures, mismatch.String())
+			}
+			failures = append(failures, tr.Comments()...)
+		}
+		if len(failures) > 0 {
+			tc.Failure = &junitMessage{Message: failures[0], Body: strings.Join(failures, "\n")}
+			suite.Failures++
+		}
+		if exceptions := tr.Exceptions(); len(exceptions) > 0 {
+			tc.Error = &junitMessage{Message: exceptions[0], Body: strings.Join(exceptions, "\n")}
+			suite.Errors++
+		} else if !validated {
+			tc.Skipped = &junitMessage{Message: "no deadlock annotations"}
+			suite.Skipped++
+		}
+		suite.Tests++
+		suite.Cases = append(suite.Cases, tc)
+	}
+	for _, tr := range sortedResults(r.Results) {
+		addCase(tr, true)
+	}
+	for _, tr := range sortedResults(r.Unvalidated) {
+		addCase(tr, false)
+	}
+
+	slices.SortFunc(report.Suites, func(s1, s2 junitTestSuite) int {
+		return strings.Compare(s1.Name, s2.Name)
+	})
+	for _, suite := range report.Suites {
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+		report.Skipped += suite.Skipped
+	}
+
+	content, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), content...), nil
+}
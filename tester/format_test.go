@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func formatTestReport() *Report {
+	expected := ExpectedDeadlocks{
+		Target: "deadlock/foo",
+		Deadlocks: []ExpectedDeadlock{{
+			Position:     token.Position{Filename: "main.go", Line: 10},
+			FunctionName: "main.main.func1",
+			Expression:   &ast.Ident{Name: "true"},
+		}},
+	}
+	found := &TargetReport{
+		Config:            Config{maxProcs1, deadlockDetectionCollect},
+		TraceFile:         "results-0/foo-1",
+		ExpectedDeadlocks: expected,
+		Trace: Trace{Deadlocks: []TraceDeadlock{{
+			FunctionName: "main.main.func1",
+			Count:        1,
+			Goid:         18,
+			WaitReason:   "chan receive",
+			CreatedBy:    TraceFrame{Function: "main.main", File: "main.go", Line: 10},
+			CreatorGoid:  1,
+		}}},
+	}
+	unexpected := &TargetReport{
+		Config:            Config{maxProcs2, deadlockDetectionCollect},
+		TraceFile:         "results-0/foo-2",
+		Exception:         errors.New("exit status 2"),
+		ExpectedDeadlocks: expected,
+		Trace: Trace{Deadlocks: []TraceDeadlock{
+			{FunctionName: "main.main.func1", Count: 1},
+			{FunctionName: "main.main.func2", Count: 1},
+		}},
+	}
+	return &Report{Results: map[string]*TargetReport{
+		found.TraceFile:      found,
+		unexpected.TraceFile: unexpected,
+	}}
+}
+
+func TestReportJSON(t *testing.T) {
+	content, err := formatTestReport().JSON()
+	require.NoError(t, err)
+
+	var report jsonReport
+	require.NoError(t, json.Unmarshal(content, &report))
+	require.Len(t, report.Results, 2)
+
+	found := report.Results[0]
+	require.Equal(t, "deadlock/foo", found.Target)
+	require.Equal(t, map[string]string{"GOMAXPROCS": "1", "gcdetectdeadlocks": "1"}, found.Settings)
+	require.True(t, found.Validated)
+	require.Equal(t, []jsonExpectedDeadlock{{
+		File: "main.go", Line: 10, Function: "main.main.func1", Expression: "true",
+	}}, found.ExpectedDeadlocks)
+	require.Len(t, found.Deadlocks, 1)
+	require.Equal(t, &jsonFrame{Function: "main.main", File: "main.go", Line: 10}, found.Deadlocks[0].CreatedBy)
+	require.Equal(t, 1, found.CorrectDeadlockFound)
+	require.Empty(t, found.Mismatches)
+	require.Empty(t, found.Exceptions)
+
+	unexpected := report.Results[1]
+	require.Len(t, unexpected.Mismatches, 1)
+	require.Equal(t, "unexpected", unexpected.Mismatches[0].Kind)
+	require.Equal(t, "main.main.func2", unexpected.Mismatches[0].Function)
+	require.Nil(t, unexpected.Mismatches[0].Expected)
+	require.Equal(t, []string{"exit status 2"}, unexpected.Exceptions)
+}
+
+func TestReportJUnit(t *testing.T) {
+	r := formatTestReport()
+	// Runs of targets without deadlock annotations are skipped,
+	// unless they raise exceptions.
+	for i, exception := range []error{nil, errors.New("exit status 2")} {
+		config := Config{maxProcs(i + 1), deadlockDetectionCollect}
+		r.AppendUnvalidated(TargetReport{
+			Config:            config,
+			TraceFile:         "results-0/bar-" + config.Name(),
+			Exception:         exception,
+			ExpectedDeadlocks: ExpectedDeadlocks{Target: "correct/bar"},
+			Trace:             Trace{Deadlocks: []TraceDeadlock{{FunctionName: "main.main.func1", Count: 1}}},
+		})
+	}
+	content, err := r.JUnit()
+	require.NoError(t, err)
+
+	var report junitTestSuites
+	require.NoError(t, xml.Unmarshal(content, &report))
+	require.Equal(t, 4, report.Tests)
+	require.Equal(t, 1, report.Failures)
+	require.Equal(t, 2, report.Errors)
+	require.Equal(t, 1, report.Skipped)
+	require.Len(t, report.Suites, 2)
+
+	unvalidated := report.Suites[0]
+	require.Equal(t, "correct/bar", unvalidated.Name)
+	require.Equal(t, 2, unvalidated.Tests)
+	require.Len(t, unvalidated.Cases, 2)
+	require.Nil(t, unvalidated.Cases[0].Failure)
+	require.Equal(t, "no deadlock annotations", unvalidated.Cases[0].Skipped.Message)
+	require.Nil(t, unvalidated.Cases[1].Failure)
+	require.Nil(t, unvalidated.Cases[1].Skipped)
+	require.Equal(t, "exit status 2", unvalidated.Cases[1].Error.Message)
+
+	suite := report.Suites[1]
+	require.Equal(t, "deadlock/foo", suite.Name)
+	require.Len(t, suite.Cases, 2)
+	require.Nil(t, suite.Cases[0].Failure)
+	require.Nil(t, suite.Cases[0].Error)
+	require.Equal(t, "results-0/foo-1", suite.Cases[0].SystemOut)
+	require.Equal(t, "Unexpected DL: main.main.func2 (1)", suite.Cases[1].Failure.Message)
+	require.Equal(t, "exit status 2", suite.Cases[1].Error.Message)
+}
+
+func TestReportHTML(t *testing.T) {
+	r := formatTestReport()
+	r.Results["results-0/foo-2"].RawTrace = []byte("goroutine 1 [<chan receive>]:\n")
+	content, err := r.HTML()
+	require.NoError(t, err)
+	html := string(content)
+
+	// The detection table lists the annotation, with the runs that expected it.
+	require.Contains(t, html, "<td>deadlock/foo</td><td>main.go:10</td><td>1/1</td><td>1/1</td><td>100.00%</td>")
+	require.Contains(t, html, "<summary>2 runs</summary>")
+	// They link to the trace files, instead of embedding every trace.
+	require.Contains(t, html, `<a href="results-0/foo-1">GOMAXPROCS-1-gcdetectdeadlocks-1 #0</a>`)
+	require.Contains(t, html, `<a href="results-0/foo-2">GOMAXPROCS-2-gcdetectdeadlocks-1 #0</a>`)
+	// The mismatch browser lists mismatches and exceptions, by kind.
+	require.Contains(t, html, `<option value="Unexpected">Unexpected (1)</option>`)
+	require.Contains(t, html, `<td>Exception</td><td>exit status 2</td>`)
+	require.Contains(t, html, "<td>Unexpected DL: main.main.func2 (1)</td>")
+	require.Contains(t, html, `<a href="#trace-1">GOMAXPROCS-2-gcdetectdeadlocks-1</a>`)
+	// Only the traces of the runs in the mismatch browser are embedded, escaped.
+	require.Contains(t, html, `<details id="trace-1">`)
+	require.Contains(t, html, "goroutine 1 [&lt;chan receive&gt;]:")
+	require.Equal(t, 1, strings.Count(html, "<details id=\"trace-"))
+	// Slowdowns are only plotted in performance measurement mode.
+	require.NotContains(t, html, "<svg")
+
+	// Links to trace files are relative to the report.
+	defer func(dest string) { reportDest = dest }(reportDest)
+	reportDest = filepath.Join("out", "report.html")
+	content, err = r.HTML()
+	require.NoError(t, err)
+	require.Contains(t, string(content), `<a href="../results-0/foo-1">GOMAXPROCS-1-gcdetectdeadlocks-1 #0</a>`)
+}
+
+func TestSlowdownBoxPlot(t *testing.T) {
+	plot := string(slowdownBoxPlot([]string{"Correct", "Deadlocking"}, []BoxMetrics[float64]{
+		BoxPlotMetrics([]float64{-1, 0, 0.5, 1, 2}),
+		{},
+	}, []int{5, 0}))
+	require.True(t, strings.HasPrefix(plot, "<svg"))
+	require.True(t, strings.HasSuffix(plot, "</svg>"))
+	require.Contains(t, plot, "Correct (5)")
+	require.Contains(t, plot, "Deadlocking (0)")
+	// Only the box of correct targets is drawn.
+	require.Equal(t, 1, strings.Count(plot, `fill="#e8e8e8"`))
+}
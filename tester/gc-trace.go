@@ -53,6 +53,35 @@ type gcTrace struct {
 	processors int
 }
 
+// gcMetric is a measurement of a GC cycle, in microseconds.
+type gcMetric struct {
+	name string
+	get  func(gcTrace) float64
+}
+
+// gcMetrics are the wall-clock and CPU times of the mark phase and of mark
+// termination, where partial deadlocks are detected, of every GC cycle.
+var gcMetrics = []gcMetric{
+	{"Mark wall", func(gc gcTrace) float64 { return gc.microseconds(gc.clockMarkTime) }},
+	{"Mark termination wall", func(gc gcTrace) float64 { return gc.microseconds(gc.clockTermTime) }},
+	{"Mark CPU", func(gc gcTrace) float64 { return gc.microseconds(gc.cpuMarkTime) }},
+	{"Mark termination CPU", func(gc gcTrace) float64 { return gc.microseconds(gc.cpuTermTime) }},
+}
+
+// microseconds converts a time of the GC trace message to microseconds.
+func (gc gcTrace) microseconds(t float64) float64 {
+	switch gc.timeUnit {
+	case "ns":
+		return t / 1000
+	case "ms":
+		return t * 1000
+	case "s":
+		return t * 1000000
+	}
+	// Microseconds are written as "μs" or "µs".
+	return t
+}
+
 // ParseGCTrace takes a GC trace message raw string and parses it into a gcTrace.
 // If the message is ill-formatted, an error is returned.
 func ParseGCTrace(gcMsg string) (gc gcTrace, err error) {
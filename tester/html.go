@@ -0,0 +1,507 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// htmlTraceLimit is the size beyond which traces are truncated in HTML reports.
+const htmlTraceLimit = 64 << 10
+
+type (
+	// htmlReport holds the contents of an HTML report.
+	htmlReport struct {
+		Runs, Targets int
+		Slowdowns     *htmlSlowdowns
+		Detections    []htmlDetections
+		// Kinds counts the entries of the mismatch browser, by kind.
+		Kinds      []htmlKind
+		Mismatches []htmlMismatch
+		Traces     []htmlTrace
+	}
+
+	// htmlSlowdowns holds the box plots of the slowdown of the GC with deadlock
+	// detection, and their percentiles.
+	htmlSlowdowns struct {
+		Plot        template.HTML
+		Percentiles []htmlPercentile
+	}
+
+	// htmlPercentile is a percentile of the slowdowns of correct and deadlocking targets.
+	htmlPercentile struct {
+		Name, Correct, Deadlocking string
+	}
+
+	// htmlDetections is the detection table of a build mode.
+	htmlDetections struct {
+		// BuildMode is empty if the results have a single build mode.
+		BuildMode  string
+		Procs      []string
+		Rows       []htmlDetection
+		Aggregated htmlDetection
+	}
+
+	// htmlDetection holds the detection rate of an annotation, and the runs that expected it.
+	htmlDetection struct {
+		Benchmark string
+		// File is the file of the annotation, relative to the benchmark.
+		File            string
+		Line            int
+		Rates           []string
+		Total, Interval string
+		// Partial is set if the expected deadlocks were missed by some runs.
+		Partial bool
+		Runs    []htmlRun
+	}
+
+	// htmlRun is a run that expected the deadlocks of an annotation.
+	htmlRun struct {
+		Config string
+		Repeat int
+		Found  bool
+		// TraceHref links to the trace file of the run, which is not embedded.
+		TraceHref string
+	}
+
+	// htmlKind is a kind of entry of the mismatch browser.
+	htmlKind struct {
+		Name  string
+		Count int
+	}
+
+	// htmlMismatch is an entry of the mismatch browser: a deadlock mismatch,
+	// exception or comment of a run.
+	htmlMismatch struct {
+		Target, Config string
+		Repeat         int
+		Kind, Message  string
+		Trace          string
+	}
+
+	// htmlTrace is the trace of a run, embedded in the report.
+	htmlTrace struct {
+		ID, Target, Config, File string
+		// Href links to the trace file, which holds the full trace.
+		Href      string
+		Repeat    int
+		Content   string
+		Truncated bool
+	}
+)
+
+// HTML produces a self-contained HTML report, with the box plots of the
+// slowdown of the GC with deadlock detection, with `-perf`, the detection
+// rates of every annotation with the runs that expected them, and a browser
+// of the deadlock mismatches, exceptions and comments of every run. Only the
+// traces of the runs in the browser are embedded in the report; the runs of
+// the detection table link to their trace files.
+func (r *Report) HTML() ([]byte, error) {
+	report := htmlReport{Runs: len(r.Results)}
+
+	// Traces are numbered in the order of their files, and embedded once,
+	// however often they are referenced.
+	results := sortedResults(r.Results)
+	traceIDs := make(map[*TargetReport]int, len(results))
+	for i, tr := range results {
+		traceIDs[tr] = i
+	}
+	referenced := make([]bool, len(results))
+	traceID := func(tr *TargetReport) string {
+		referenced[traceIDs[tr]] = true
+		return "trace-" + strconv.Itoa(traceIDs[tr])
+	}
+
+	targets := make(map[string]struct{})
+	for _, tr := range r.Results {
+		targets[tr.ExpectedDeadlocks.Target] = struct{}{}
+	}
+	report.Targets = len(targets)
+	// As for the TeX box plot, slowdowns are only measured in performance measurement mode.
+	if perf {
+		report.Slowdowns = r.htmlSlowdowns()
+	}
+
+	modes := r.BuildModes()
+	for _, mode := range modes {
+		detections := r.ForBuildMode(mode).htmlDetections()
+		if len(modes) > 1 {
+			detections.BuildMode = string(mode)
+		}
+		report.Detections = append(report.Detections, detections)
+	}
+
+	kinds := make(map[string]int)
+	for _, tr := range results {
+		// Deadlocks are only validated if deadlock detection is enabled.
+		if !tr.HasDeadlockDetection() {
+			continue
+		}
+		if tr.Diff == nil {
+			tr.CompareWithTrace()
+		}
+		add := func(kind, message string) {
+			report.Mismatches = append(report.Mismatches, htmlMismatch{
+				Target:  tr.ExpectedDeadlocks.Target,
+				Config:  tr.Config.Name(),
+				Repeat:  tr.Repeat,
+				Kind:    kind,
+				Message: message,
+				Trace:   traceID(tr),
+			})
+			kinds[kind]++
+		}
+		for _, mismatch := range tr.Diff.Mismatches {
+			switch {
+			case mismatch.Unexpected:
+				add("Unexpected", mismatch.String())
+			case mismatch.WrongWaitReason:
+				add("Wrong wait reason", mismatch.String())
+			default:
+				add("Count", mismatch.String())
+			}
+		}
+		for _, exception := range tr.Exceptions() {
+			add("Exception", exception)
+		}
+		for _, comment := range tr.Comments() {
+			add("Comment", comment)
+		}
+	}
+	for _, kind := range []string{"Unexpected", "Wrong wait reason", "Count", "Exception", "Comment"} {
+		if kinds[kind] > 0 {
+			report.Kinds = append(report.Kinds, htmlKind{kind, kinds[kind]})
+		}
+	}
+
+	for i, tr := range results {
+		if !referenced[i] {
+			continue
+		}
+		trace := htmlTrace{
+			ID:      "trace-" + strconv.Itoa(i),
+			Target:  tr.ExpectedDeadlocks.Target,
+			Config:  tr.Config.Name(),
+			File:    tr.TraceFile,
+			Href:    traceHref(tr.TraceFile),
+			Repeat:  tr.Repeat,
+			Content: string(tr.RawTrace),
+		}
+		if len(trace.Content) > htmlTraceLimit {
+			trace.Content, trace.Truncated = trace.Content[:htmlTraceLimit], true
+		}
+		report.Traces = append(report.Traces, trace)
+	}
+
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, report); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// traceHref returns the link to a trace file from the HTML report, relative
+// to the directory of -report, or of the working directory if the report is
+// printed. The link is absolute if there is no relative path.
+func traceHref(file string) string {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return filepath.ToSlash(file)
+	}
+	dir, err := filepath.Abs(filepath.Dir(reportDest))
+	if err != nil {
+		return filepath.ToSlash(abs)
+	}
+	rel, err := filepath.Rel(dir, abs)
+	if err != nil {
+		return filepath.ToSlash(abs)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// htmlDetections produces the detection table of the report, regardless of build modes.
+// Unlike the tabulated report, every annotation is listed.
+func (r *Report) htmlDetections() htmlDetections {
+	procconfigs, entries := r.tabEntries()
+
+	detections := htmlDetections{Procs: make([]string, len(procconfigs))}
+	for p, i := range procconfigs {
+		detections.Procs[i] = strconv.Itoa(p) + "P"
+		if p < 0 {
+			// GOMAXPROCS is not part of the configurations.
+			detections.Procs[i] = "default"
+		}
+	}
+
+	row := func(entry tabEntry) htmlDetection {
+		detection := htmlDetection{Rates: make([]string, len(entry.pconfig))}
+		for i, p := range entry.pconfig {
+			detection.Rates[i] = fmt.Sprintf("%d/%d", p, entry.expected[i])
+			if entry.expected[i] == 0 {
+				// No deadlocks are expected for this GOMAXPROCS value.
+				detection.Rates[i] = "-"
+			}
+		}
+		found, expected := entry.found()
+		if expected > 0 {
+			detection.Total = strconv.FormatFloat(float64(found)/float64(expected)*100, 'f', 2, 64) + "%"
+		}
+		detection.Interval = formatInterval(found, expected)
+		detection.Partial = found < expected
+		return detection
+	}
+
+	aggregated := tabEntry{
+		pconfig:  make([]int, len(procconfigs)),
+		expected: make([]int, len(procconfigs)),
+	}
+	for _, entry := range entries {
+		for i, p := range entry.pconfig {
+			aggregated.pconfig[i] += p
+			aggregated.expected[i] += entry.expected[i]
+		}
+
+		detection := row(entry)
+		detection.Benchmark = entry.target
+		detection.File = entry.file
+		if rel, err := filepath.Rel(entry.target, entry.file); err == nil && filepath.IsLocal(rel) {
+			detection.File = rel
+		}
+		detection.Line = entry.line
+		slices.SortFunc(entry.runs, func(r1, r2 tabRun) int {
+			return strings.Compare(r1.report.TraceFile, r2.report.TraceFile)
+		})
+		for _, run := range entry.runs {
+			detection.Runs = append(detection.Runs, htmlRun{
+				Config:    run.report.Config.Name(),
+				Repeat:    run.report.Repeat,
+				Found:     run.found,
+				TraceHref: traceHref(run.report.TraceFile),
+			})
+		}
+		detections.Rows = append(detections.Rows, detection)
+	}
+	slices.SortFunc(detections.Rows, func(d1, d2 htmlDetection) int {
+		if c := strings.Compare(d1.Benchmark, d2.Benchmark); c != 0 {
+			return c
+		}
+		if c := strings.Compare(d1.File, d2.File); c != 0 {
+			return c
+		}
+		return d1.Line - d2.Line
+	})
+
+	detections.Aggregated = row(aggregated)
+	detections.Aggregated.Benchmark = "Aggregated"
+	return detections
+}
+
+// htmlSlowdowns produces the box plots of the slowdown of the average mark CPU
+// time of the GC with deadlock detection, for correct and deadlocking targets,
+// with the same scale and percentiles as OverheadMeasurementsBoxplot.
+func (r *Report) htmlSlowdowns() *htmlSlowdowns {
+	perfsCorrect, perfsDeadlock := r.slowdowns()
+
+	normalized := func(perfs []GCPerf) (logs, pcts []float64) {
+		for _, perf := range perfs {
+			logs = append(logs, NormalizeSlowdown(perf.avgMarkCPUOff, perf.avgMarkCPUOn))
+			pcts = append(pcts, perf.avgMarkCPUOn/perf.avgMarkCPUOff)
+		}
+		return logs, pcts
+	}
+	correctLog, correctPct := normalized(perfsCorrect)
+	deadlockLog, deadlockPct := normalized(perfsDeadlock)
+
+	slowdowns := &htmlSlowdowns{
+		Plot: slowdownBoxPlot([]string{"Correct", "Deadlocking"}, []BoxMetrics[float64]{
+			BoxPlotMetrics(correctLog), BoxPlotMetrics(deadlockLog),
+		}, []int{len(correctLog), len(deadlockLog)}),
+	}
+
+	correctBox, deadlockBox := BoxPlotMetrics(correctPct), BoxPlotMetrics(deadlockPct)
+	cell := func(perfs []GCPerf, slowdown float64, fi func(int) int) string {
+		if len(perfs) == 0 {
+			return "-"
+		}
+		perf := perfs[fi(len(perfs))]
+		return fmt.Sprintf("%.2f (%.2fµs ⇒ %.2fµs)", slowdown, perf.avgMarkCPUOff, perf.avgMarkCPUOn)
+	}
+	for _, p := range []struct {
+		name string
+		get  func(BoxMetrics[float64]) float64
+		fi   func(int) int
+	}{
+		{"Min", func(m BoxMetrics[float64]) float64 { return m.Min }, func(int) int { return 0 }},
+		{"P1", func(m BoxMetrics[float64]) float64 { return m.Q0 }, func(l int) int { return l / 100 }},
+		{"P25", func(m BoxMetrics[float64]) float64 { return m.Q1 }, func(l int) int { return l / 4 }},
+		{"P50", func(m BoxMetrics[float64]) float64 { return m.Q2 }, func(l int) int { return l / 2 }},
+		{"P75", func(m BoxMetrics[float64]) float64 { return m.Q3 }, func(l int) int { return l * 3 / 4 }},
+		{"P99", func(m BoxMetrics[float64]) float64 { return m.Q4 }, func(l int) int { return l * 99 / 100 }},
+		{"Max", func(m BoxMetrics[float64]) float64 { return m.Max }, func(l int) int { return l - 1 }},
+	} {
+		slowdowns.Percentiles = append(slowdowns.Percentiles, htmlPercentile{
+			Name:        p.name,
+			Correct:     cell(perfsCorrect, p.get(correctBox), p.fi),
+			Deadlocking: cell(perfsDeadlock, p.get(deadlockBox), p.fi),
+		})
+	}
+	return slowdowns
+}
+
+// slowdownBoxPlot draws labelled box plots of normalized slowdowns, given the
+// box plot metrics and number of samples of each, as an SVG image. As in the
+// TeX box plot, whiskers are at the 1st and 99th percentiles, and the values
+// beyond are drawn as outliers.
+func slowdownBoxPlot(labels []string, boxes []BoxMetrics[float64], samples []int) template.HTML {
+	const (
+		width, height            = 560, 380
+		left, right, top, bottom = 70, 20, 20, 40
+		boxWidth                 = 80
+		// The normalized slowdowns are drawn between -limit and limit.
+		limit = 3.5
+	)
+	plotWidth, plotHeight := float64(width-left-right), float64(height-top-bottom)
+	y := func(v float64) float64 {
+		v = max(-limit, min(limit, v))
+		return top + (limit-v)/(2*limit)*plotHeight
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`, width, height, width, height)
+	b.WriteString("\n")
+	// The labels of the normalized slowdowns, as in the TeX box plot.
+	for i, label := range []string{"0.1×", "0.5×", "0.9×", "1×", "1.1×", "2×", "10×"} {
+		ty := y(float64(i - 3))
+		fmt.Fprintf(&b, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#ddd"/>`, left, ty, width-right, ty)
+		fmt.Fprintf(&b, `<text x="%d" y="%.1f" text-anchor="end" dominant-baseline="middle">%s</text>`+"\n", left-6, ty, label)
+	}
+	fmt.Fprintf(&b, `<text transform="translate(14 %.1f) rotate(-90)" text-anchor="middle">Slowdown</text>`+"\n", top+plotHeight/2)
+	fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%.0f" height="%.0f" fill="none" stroke="black"/>`+"\n", left, top, plotWidth, plotHeight)
+
+	for i, m := range boxes {
+		cx := left + plotWidth*(float64(i)+0.5)/float64(len(boxes))
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d" text-anchor="middle" font-weight="bold">%s (%d)</text>`+"\n", cx, height-bottom+20, template.HTMLEscapeString(labels[i]), samples[i])
+		if samples[i] == 0 {
+			continue
+		}
+		x0, x1 := cx-boxWidth/2, cx+boxWidth/2
+		fmt.Fprintf(&b, `<g stroke="black" stroke-width="1.5"><title>P1 %.2f, P25 %.2f, P50 %.2f, P75 %.2f, P99 %.2f</title>`, m.Q0, m.Q1, m.Q2, m.Q3, m.Q4)
+		fmt.Fprintf(&b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f"/>`, cx, y(m.Q0), cx, y(m.Q1))
+		fmt.Fprintf(&b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f"/>`, cx, y(m.Q3), cx, y(m.Q4))
+		fmt.Fprintf(&b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f"/>`, cx-boxWidth/4, y(m.Q0), cx+boxWidth/4, y(m.Q0))
+		fmt.Fprintf(&b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f"/>`, cx-boxWidth/4, y(m.Q4), cx+boxWidth/4, y(m.Q4))
+		fmt.Fprintf(&b, `<rect x="%.1f" y="%.1f" width="%d" height="%.1f" fill="#e8e8e8"/>`, x0, y(m.Q3), boxWidth, y(m.Q1)-y(m.Q3))
+		fmt.Fprintf(&b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke-width="3"/>`, x0, y(m.Q2), x1, y(m.Q2))
+		b.WriteString("</g>\n")
+		for _, outlier := range slices.Concat(m.SmallOutliers, m.LargeOutliers) {
+			fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="3" fill="none" stroke="black"><title>%.2f</title></circle>`+"\n", cx, y(outlier), outlier)
+		}
+	}
+	b.WriteString("</svg>")
+	return template.HTML(b.String())
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Golf report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 0.2em 0.5em; text-align: left; vertical-align: top; }
+th { background: #f0f0f0; }
+tr.partial { background: #fff4e0; }
+tr.aggregated { font-weight: bold; }
+td.missed { color: #b00; }
+pre { background: #f8f8f8; padding: 0.5em; overflow-x: auto; max-height: 40em; }
+details > summary { cursor: pointer; }
+</style>
+</head>
+<body>
+<h1>Golf report</h1>
+<p>{{.Runs}} runs of {{.Targets}} targets.</p>
+
+{{with .Slowdowns}}
+<h2>Slowdown</h2>
+<p>Slowdown of the average mark CPU time of the GC with deadlock detection, per run.</p>
+{{.Plot}}
+<table>
+<tr><th>Percentile</th><th>Correct</th><th>Deadlocking</th></tr>
+{{range .Percentiles}}<tr><td>{{.Name}}</td><td>{{.Correct}}</td><td>{{.Deadlocking}}</td></tr>
+{{end}}</table>
+{{end}}
+
+<h2>Detection rates</h2>
+{{range .Detections}}
+{{with .BuildMode}}<h3>Build mode: {{.}}</h3>{{end}}
+<table>
+<tr><th>Benchmark</th><th>Position</th>{{range .Procs}}<th>{{.}}</th>{{end}}<th>Total</th><th>95% CI</th><th>Runs</th></tr>
+{{range .Rows}}<tr{{if .Partial}} class="partial"{{end}}><td>{{.Benchmark}}</td><td>{{.File}}:{{.Line}}</td>{{range .Rates}}<td>{{.}}</td>{{end}}<td>{{.Total}}</td><td>{{.Interval}}</td>
+<td><details><summary>{{len .Runs}} runs</summary><ul>
+{{range .Runs}}<li><a href="{{.TraceHref}}">{{.Config}} #{{.Repeat}}</a>{{if not .Found}} <span class="missed">missed</span>{{end}}</li>
+{{end}}</ul></details></td></tr>
+{{end}}{{with .Aggregated}}<tr class="aggregated"><td>{{.Benchmark}}</td><td></td>{{range .Rates}}<td>{{.}}</td>{{end}}<td>{{.Total}}</td><td>{{.Interval}}</td><td></td></tr>{{end}}
+</table>
+{{end}}
+
+<h2>Mismatches</h2>
+{{if .Mismatches}}
+<p>
+<select id="kind">
+<option value="">All kinds</option>
+{{range .Kinds}}<option value="{{.Name}}">{{.Name}} ({{.Count}})</option>
+{{end}}</select>
+<input id="filter" type="search" placeholder="Filter" size="40">
+</p>
+<table id="mismatches">
+<tr><th>Target</th><th>Configuration</th><th>Repeat</th><th>Kind</th><th>Message</th></tr>
+{{range .Mismatches}}<tr data-kind="{{.Kind}}"><td>{{.Target}}</td><td><a href="#{{.Trace}}">{{.Config}}</a></td><td>{{.Repeat}}</td><td>{{.Kind}}</td><td>{{.Message}}</td></tr>
+{{end}}</table>
+<script>
+(function() {
+	var kind = document.getElementById("kind"), filter = document.getElementById("filter");
+	function update() {
+		var rows = document.querySelectorAll("#mismatches tr[data-kind]");
+		var text = filter.value.toLowerCase();
+		for (var i = 0; i < rows.length; i++) {
+			var row = rows[i];
+			var shown = (!kind.value || row.dataset.kind === kind.value) &&
+				row.textContent.toLowerCase().indexOf(text) >= 0;
+			row.style.display = shown ? "" : "none";
+		}
+	}
+	kind.addEventListener("change", update);
+	filter.addEventListener("input", update);
+})();
+</script>
+{{else}}
+<p>No mismatches.</p>
+{{end}}
+
+<h2>Traces</h2>
+{{range .Traces}}
+<details id="{{.ID}}"><summary>{{.Target}} {{.Config}} #{{.Repeat}} ({{.File}})</summary>
+<pre>{{.Content}}</pre>
+{{if .Truncated}}<p>Truncated; the full trace is in <a href="{{.Href}}">{{.File}}</a>.</p>{{end}}
+</details>
+{{end}}
+<script>
+// Open the trace linked to.
+function openTrace() {
+	var target = location.hash && document.getElementById(location.hash.slice(1));
+	if (target && target.tagName === "DETAILS") {
+		target.open = true;
+		target.scrollIntoView();
+	}
+}
+window.addEventListener("hashchange", openTrace);
+openTrace();
+</script>
+</body>
+</html>
+`))
@@ -12,6 +12,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
 	"syscall"
@@ -28,6 +29,11 @@ var (
 	matchExamplesStr     = ""
 	dontMatchExamplesStr = ""
 	reportDest           = ""
+	reportFormat         = formatText
+	matrixFile           = ""
+	buildCache           = ""
+	collectMetrics       = false
+	matrixOverrides      matrixAssignments
 )
 
 func RunBenchmark() {
@@ -38,7 +44,16 @@ func RunBenchmark() {
 		ticket: make(chan struct{}, parallelism),
 	}
 
-	compiledTargets, mu := make(map[string]struct{}), &sync.Mutex{}
+	if buildCache == "" {
+		// Only reuse binaries within this run.
+		dir, err := os.MkdirTemp("", "golf-tester")
+		if err != nil {
+			log.Fatal("Failed to create build directory:", err)
+		}
+		defer os.RemoveAll(dir)
+		buildCache = dir
+	}
+	compiledTargets := &targetCache{Dir: buildCache}
 
 	// Get every configuration
 	for i := 1; i <= numberOfRepeats; i++ {
@@ -57,6 +72,7 @@ func RunBenchmark() {
 					defer wg.Done()
 
 					cwg := &sync.WaitGroup{}
+					testPackages := make(map[string]bool)
 					filepath.WalkDir(testFiles, func(p string, d fs.DirEntry, err error) error {
 						// If an error is encountered, bail early.
 						if err != nil {
@@ -67,8 +83,20 @@ func RunBenchmark() {
 							return nil
 						}
 
-						// Ignore non-"main.go" files, or examples that match the "don't match" regular expression
-						if dontMatchExamplesStr != "" && dontMatchExamples.MatchString(p) || path.Base(p) != "main.go" {
+						// Targets are "main.go" files, or test packages, found at their first "_test.go" file.
+						var t target
+						switch {
+						case path.Base(p) == "main.go":
+							t = target{Dir: path.Dir(p), Metrics: collectMetrics}
+						case strings.HasSuffix(p, "_test.go") && !testPackages[path.Dir(p)]:
+							testPackages[path.Dir(p)] = true
+							t = target{Dir: path.Dir(p), Test: true, Metrics: collectMetrics}
+						default:
+							return nil
+						}
+
+						// Ignore examples that match the "don't match" regular expression
+						if dontMatchExamplesStr != "" && dontMatchExamples.MatchString(p) {
 							return nil
 						}
 
@@ -80,128 +108,29 @@ func RunBenchmark() {
 
 						// Run all tests concurrently.
 						cwg.Add(1)
-						go func(p string) {
+						go func(t target) {
 							defer func() {
 								<-fullReport.ticket
 								cwg.Done()
 							}()
 							fullReport.ticket <- struct{}{}
-							// Create result directory.
-							traceFile := path.Join(path.Dir(path.Join(RESULT, strings.TrimPrefix(p, testFiles))), c.Name())
-							traceDir := path.Dir(traceFile)
-
-							report := TargetReport{
-								Config:    c,
-								TraceFile: traceFile,
-								Repeat:    i,
-							}
-
-							// Create all needed parent directories if they don't exist.
-							if err := os.MkdirAll(traceDir, os.ModePerm); err != nil {
-								report.Exception = errors.New("Failed to create parent directories: " + traceFile + ", Configuration:" + err.Error())
-								fullReport.Append(report)
-								return
-							}
-
-							// Construct a buffer into which to dump the resulting trace.
-							fs, err := os.Create(traceFile + ".tmp")
-							if err != nil {
-								report.Exception = errors.New("Failed to create result file: " + traceFile + ": " + err.Error())
-								fullReport.Append(report)
-								return
-							}
 
-							report.ExpectedDeadlocks, err = getDeadlockExpectations(path.Dir(p), c)
-							// Failure to extract expected deadlock annotations should be fatal.
-							if err != nil {
-								log.Fatal("Failed to get expected deadlocks:", err)
-							}
-
-							done := make(chan struct{})
 							gocmd := goCompiler
 							if perf && !c.HasDeadlockDetection() {
 								gocmd = baselineCompiler
 							}
-							compileGo := exec.Command(gocmd, "build", "main.go")
-							compileGo.Dir = path.Dir(p)
-							compileGo.Env = append(os.Environ(), "GO_GCFLAGS=-race")
-							compileGo.Stderr = fs
-
-							runGo := exec.Command("./main")
-							runGo.Dir = path.Dir(p)
-							runGo.Stdout = fs
-							runGo.Stderr = fs
-							runGo.Env = append(append(os.Environ(), c.Flags()...), "GO_GCFLAGS=-race", "GOTRACEBACK=system")
-							go func() {
-								defer func() { done <- struct{}{} }()
-								mu.Lock()
-								if _, ok := compiledTargets[path.Dir(p)]; !ok {
-									// Clean out old binary.
-									os.RemoveAll(path.Dir(p) + "/main")
-									if err := compileGo.Run(); err != nil {
-										mu.Unlock()
-										fmt.Println(path.Dir(p), "["+c.String()+"] Compile:", err)
-										report.Exception = errors.New("compilation failure")
-										return
-									}
-									compiledTargets[path.Dir(p)] = struct{}{}
-								}
-								mu.Unlock()
-								if err := runGo.Run(); err != nil {
-									report.Exception = errors.New("runtime failure")
-									fmt.Println(c.String()+" Run:", traceFile, err)
-								}
-							}()
+							compiled := compiledTargets.compile(t, gocmd, c.BuildFlags())
 
-							select {
-							case <-time.After(200 * time.Second):
-								report.Exception = errors.New("go runtime timed out")
-								report.ExpectedDeadlocks.Target = path.Dir(p)
-								fullReport.Append(report)
-								if compileGo != nil && compileGo.Process != nil && compileGo.Process.Pid > 0 {
-									compileGo.Process.Signal(os.Interrupt)
-								}
-								if runGo != nil && runGo.Process != nil && runGo.Process.Pid > 0 {
-									runGo.Process.Signal(syscall.SIGQUIT)
-								}
+							// Test functions of test packages have their own result directories.
+							resultDir := path.Join(RESULT, strings.TrimPrefix(t.Dir, testFiles))
+							if !t.Test || compiled.Err != nil {
+								runTarget(&fullReport, t, "", c, i, path.Join(resultDir, c.Name()), compiled)
 								return
-							case <-done:
 							}
-							fs.Close()
-
-							fs, _ = os.Open(traceFile + ".tmp")
-							report.RawTrace = RemoveGoGCTrace(fs)
-							fs.Close()
-
-							os.Remove(traceFile + ".tmp")
-
-							report.Trace, err = ExtractTrace(report.RawTrace)
-							report.Exception = errors.Join(report.Exception, err)
-
-							if err := report.EmitToFile(); err != nil {
-								log.Fatal("Failed to write trace to file:", err)
+							for _, test := range compiled.Tests {
+								runTarget(&fullReport, t, test, c, i, path.Join(resultDir, test, c.Name()), compiled)
 							}
-
-							// If deadlock detection is disabled, we should not have any deadlock reports.
-							// Otherwise, we are dealing with a serious implementation bug.
-							if !c.HasDeadlockDetection() {
-								if len(report.Trace.Deadlocks) > 0 {
-									log.Fatal("Target: ", report.Target, " Found deadlocks in trace when deadlock detection is disabled!")
-								}
-								fullReport.Append(report)
-								return
-							}
-
-							if len(report.Deadlocks) == 0 {
-								if report.IsDeadlock() {
-									report.Exception = errors.Join(report.Exception, errors.New("missing deadlock annotations in deadlock example"))
-									fullReport.Append(report)
-								}
-								return
-							}
-
-							fullReport.Append(report)
-						}(p)
+						}(t)
 						return nil
 					})
 
@@ -215,10 +144,32 @@ func RunBenchmark() {
 	fullReport.Wait()
 	fmt.Println("Done with all configurations!")
 	fmt.Println("Whole benchmark took:", time.Since(start))
+	writeReports(&fullReport)
+}
+
+// writeReports outputs the reports of the runs in the requested format,
+// to the report destination, or to standard output if none was given.
+func writeReports(fullReport *Report) {
+	if reportFormat != formatText {
+		content, err := fullReport.Format(reportFormat)
+		if err != nil {
+			log.Fatal("Failed to format report:", err)
+		}
+		if reportDest == "" {
+			fmt.Println(string(content))
+		} else if err := os.WriteFile(reportDest, content, os.ModePerm); err != nil {
+			log.Fatal("Failed to write report:", err)
+		}
+		return
+	}
 	if reportDest == "" {
 		if !perf {
 			fmt.Println(fullReport.String())
 			fmt.Println(fullReport.Tabulated())
+			if slices.Contains(fullReport.BuildModes(), buildRace) {
+				fmt.Println()
+				fmt.Println(fullReport.Races())
+			}
 		} else {
 			fmt.Println()
 			fmt.Println("Performance results:")
@@ -232,6 +183,9 @@ func RunBenchmark() {
 		// content += "\n" + fullReport.DirAggregates("deadlock/cgo-examples")
 		// content += "\n" + fullReport.DirAggregates("deadlock/gobench/goker/blocking")
 		content += "\n\n" + fullReport.Tabulated()
+		if slices.Contains(fullReport.BuildModes(), buildRace) {
+			content += "\n\n" + fullReport.Races()
+		}
 		if err := os.WriteFile(reportDest, []byte(content), os.ModePerm); err != nil {
 			log.Fatal("Failed to write report:", err)
 		}
@@ -247,6 +201,142 @@ func RunBenchmark() {
 	}
 }
 
+// runTarget runs the compiled target, or the given Test function of a test
+// package, under the given configuration, and adds the resulting report.
+// The trace is written to traceFile. If compilation failed, the report
+// records the compiler output instead.
+func runTarget(fullReport *Report, t target, test string, c Config, repeat int, traceFile string, compiled *compiledTarget) {
+	traceDir := path.Dir(traceFile)
+
+	report := TargetReport{
+		Config:    c,
+		Name:      test,
+		TraceFile: traceFile,
+		Repeat:    repeat,
+	}
+
+	// Create all needed parent directories if they don't exist.
+	if err := os.MkdirAll(traceDir, os.ModePerm); err != nil {
+		report.Exception = errors.New("Failed to create parent directories: " + traceFile + ", Configuration:" + err.Error())
+		fullReport.Append(report)
+		return
+	}
+
+	// Construct a buffer into which to dump the resulting trace.
+	fs, err := os.Create(traceFile + ".tmp")
+	if err != nil {
+		report.Exception = errors.New("Failed to create result file: " + traceFile + ": " + err.Error())
+		fullReport.Append(report)
+		return
+	}
+
+	// expectations extracts the expected deadlocks of the target.
+	expectations := func(symbols *SymbolTable) {
+		report.ExpectedDeadlocks, err = t.expectations(test, c, symbols)
+		// Failure to extract expected deadlock annotations should be fatal.
+		if err != nil {
+			log.Fatal("Failed to get expected deadlocks:", err)
+		}
+	}
+
+	done := make(chan struct{})
+	runGo := t.runCmd(compiled.Binary, test)
+	runGo.Stdout = fs
+	runGo.Stderr = fs
+	runGo.Env = append(append(os.Environ(), c.Flags()...), "GOTRACEBACK=system")
+	metricsFile := traceFile + metricsSuffix
+	if t.Metrics {
+		// The target runs in its own directory.
+		if abs, err := filepath.Abs(metricsFile); err == nil {
+			metricsFile = abs
+		}
+		os.Remove(metricsFile)
+		runGo.Env = append(runGo.Env, metricsEnv+"="+metricsFile)
+	}
+	go func() {
+		defer func() { done <- struct{}{} }()
+		if compiled.Err != nil {
+			fs.Write(compiled.Output)
+			fmt.Println(t.Dir, "["+c.String()+"] Compile:", compiled.Err)
+			report.Exception = errors.New("compilation failure")
+			return
+		}
+		if err := runGo.Run(); err != nil {
+			var exitErr *exec.ExitError
+			if c.BuildMode() == buildRace && errors.As(err, &exitErr) && exitErr.ExitCode() == raceExitCode {
+				// Data races are reported in the trace.
+				return
+			}
+			report.Exception = errors.New("runtime failure")
+			fmt.Println(c.String()+" Run:", traceFile, err)
+		}
+	}()
+
+	select {
+	case <-time.After(targetTimeout):
+		report.Exception = errors.New("go runtime timed out")
+		// Only the positions of the expected deadlocks are needed
+		// to count them as missed.
+		expectations(nil)
+		fullReport.Append(report)
+		if runGo != nil && runGo.Process != nil && runGo.Process.Pid > 0 {
+			runGo.Process.Signal(syscall.SIGQUIT)
+		}
+		return
+	case <-done:
+	}
+	fs.Close()
+
+	expectations(compiled.Symbols)
+
+	fs, _ = os.Open(traceFile + ".tmp")
+	report.RawTrace = RemoveGoGCTrace(fs)
+	fs.Close()
+
+	os.Remove(traceFile + ".tmp")
+
+	report.Trace, err = ExtractTrace(report.RawTrace)
+	report.Exception = errors.Join(report.Exception, err)
+	if t.Metrics {
+		// Targets that crash, or that were not instrumented, write no metrics.
+		if report.Metrics, err = ReadRuntimeMetrics(metricsFile); err != nil && !os.IsNotExist(err) {
+			report.Exception = errors.Join(report.Exception, err)
+		}
+	}
+
+	if err := report.EmitToFile(); err != nil {
+		log.Fatal("Failed to write trace to file:", err)
+	}
+
+	appendRun(fullReport, report)
+}
+
+// appendRun adds the report of a completed run of a target, validated
+// against its deadlock annotations, if it has any.
+func appendRun(fullReport *Report, report TargetReport) {
+	// If deadlock detection is disabled, we should not have any deadlock reports.
+	// Otherwise, we are dealing with a serious implementation bug.
+	if !report.Config.HasDeadlockDetection() {
+		if len(report.Trace.Deadlocks) > 0 {
+			log.Fatal("Target: ", report.Target, " Found deadlocks in trace when deadlock detection is disabled!")
+		}
+		fullReport.Append(report)
+		return
+	}
+
+	if len(report.Deadlocks) == 0 {
+		if report.IsDeadlock() {
+			report.Exception = errors.Join(report.Exception, errors.New("missing deadlock annotations in deadlock example"))
+			fullReport.Append(report)
+			return
+		}
+		fullReport.AppendUnvalidated(report)
+		return
+	}
+
+	fullReport.Append(report)
+}
+
 func makeFlags() {
 	flag.BoolVar(&perf, "perf", false, "Run performance tests.")
 	flag.IntVar(&parallelism, "parallelism", runtime.GOMAXPROCS(0), "Number of parallel tests to run.")
@@ -257,6 +347,14 @@ func makeFlags() {
 	flag.StringVar(&testFiles, "tests", testFiles, "Direct the tester to a directory of benchmarks.")
 	flag.IntVar(&numberOfRepeats, "repeats", 1, "Number of times to repeat each configuration test.")
 	flag.StringVar(&reportDest, "report", "", "Destination file for final report.")
+	flag.StringVar(&reportFormat, "format", formatText, "Format of the final report: `text`, `json`, `junit` or `html`.")
+	if dir, err := os.UserCacheDir(); err == nil {
+		buildCache = filepath.Join(dir, "golf-tester")
+	}
+	flag.StringVar(&buildCache, "cache", buildCache, "Directory of compiled targets, reused across runs. If empty, targets are compiled again for every run.")
+	flag.StringVar(&matrixFile, "matrix", "", "JSON, YAML or TOML file defining the configuration matrix.")
+	flag.BoolVar(&collectMetrics, "metrics", false, "Build targets with a helper that dumps their runtime metrics at exit, for the performance report.")
+	flag.Var(&matrixOverrides, "set", "Override a key of the configuration matrix, e.g., `GOMAXPROCS=1,8`, `GODEBUG.key=0,1` or `env.KEY=a,b`. May be repeated.")
 
 	flag.Parse()
 
@@ -268,14 +366,55 @@ func makeFlags() {
 		}
 	}
 
+	if buildCache != "" {
+		if buildCacheAbs, err := filepath.Abs(buildCache); err != nil {
+			log.Fatal("Failed to get absolute path of build cache:", err)
+		} else {
+			buildCache = buildCacheAbs
+		}
+	}
+
+	switch reportFormat {
+	case formatText, formatJSON, formatJUnit, formatHTML:
+	default:
+		log.Fatalf("Unrecognized report format: %q", reportFormat)
+	}
+
 	if perf {
-		// Only run on one core for performance tests.
-		defaultvalues[2] = []configvalue{maxProcs1}
+		// Only run on one core for performance tests, unless configured otherwise.
+		matrix.GOMAXPROCS = []int{int(maxProcs1)}
+	}
+	if matrixFile != "" {
+		if err := matrix.Load(matrixFile); err != nil {
+			log.Fatal("Failed to load configuration matrix: ", err)
+		}
+	}
+	for _, assignment := range matrixOverrides {
+		if err := matrix.Set(assignment); err != nil {
+			log.Fatal("Failed to override configuration matrix: ", err)
+		}
+	}
+	if err := matrix.Validate(); err != nil {
+		log.Fatal("Invalid configuration matrix: ", err)
 	}
 }
 
 func main() {
+	// `report` regenerates the reports of earlier runs, given their result directories,
+	// and `compare` compares the detection rates of two sets of results.
+	var subcommand string
+	if len(os.Args) > 1 && (os.Args[1] == "report" || os.Args[1] == "compare") {
+		subcommand = os.Args[1]
+		os.Args = slices.Delete(os.Args, 1, 2)
+	}
 	makeFlags()
 
-	RunBenchmark()
+	switch subcommand {
+	case "report":
+		ReanalyzeResults(flag.Args())
+	case "compare":
+		CompareResults(flag.Args())
+	default:
+		RunBenchmark()
+	}
 }
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Matrix defines the configuration space of the harness, as the values
+// taken by each configuration key. Every combination of values is a configuration.
+// Keys without values are not part of the configurations.
+type Matrix struct {
+	Gcddtrace         []int `json:"gcddtrace" yaml:"gcddtrace" toml:"gcddtrace"`
+	Gcdetectdeadlocks []int `json:"gcdetectdeadlocks" yaml:"gcdetectdeadlocks" toml:"gcdetectdeadlocks"`
+	GOMAXPROCS        []int `json:"GOMAXPROCS" yaml:"GOMAXPROCS" toml:"GOMAXPROCS"`
+	Gcstoptheworld    []int `json:"gcstoptheworld" yaml:"gcstoptheworld" toml:"gcstoptheworld"`
+	// GOGC and GOMEMLIMIT control the pacing of the GC, e.g., "50", or "off".
+	GOGC       matrixValues `json:"GOGC" yaml:"GOGC" toml:"GOGC"`
+	GOMEMLIMIT matrixValues `json:"GOMEMLIMIT" yaml:"GOMEMLIMIT" toml:"GOMEMLIMIT"`
+	// Build holds the modes targets are built in: "plain", "race" or "noopt".
+	Build []string `json:"build" yaml:"build" toml:"build"`
+	// GODEBUG holds the values of additional GODEBUG keys.
+	GODEBUG map[string]matrixValues `json:"GODEBUG" yaml:"GODEBUG" toml:"GODEBUG"`
+	// Env holds the values of additional environment variables.
+	Env map[string]matrixValues `json:"env" yaml:"env" toml:"env"`
+}
+
+// matrix is the configuration space of the harness.
+var matrix = Matrix{
+	Gcddtrace:         []int{int(gcddtraceOff)},
+	Gcdetectdeadlocks: []int{int(deadlockDetectionOff), int(deadlockDetectionCollect)},
+	GOMAXPROCS:        []int{int(maxProcs1), int(maxProcs2), int(maxProcs4), int(maxProcs10)},
+}
+
+// matrixValues are the values of a GODEBUG key or environment variable.
+// Numbers are accepted in place of strings.
+type matrixValues []string
+
+func (vs *matrixValues) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*vs = nil
+	for _, r := range raw {
+		var v any
+		if err := json.Unmarshal(r, &v); err != nil {
+			return err
+		}
+		if _, ok := v.(string); !ok {
+			// Keep numbers as written.
+			v = string(r)
+		}
+		*vs = append(*vs, fmt.Sprint(v))
+	}
+	return nil
+}
+
+func (vs *matrixValues) UnmarshalTOML(data any) error {
+	raw, ok := data.([]any)
+	if !ok {
+		return fmt.Errorf("expected a list of values, got %v", data)
+	}
+	*vs = nil
+	for _, v := range raw {
+		*vs = append(*vs, fmt.Sprint(v))
+	}
+	return nil
+}
+
+// Dimensions returns the values of every configuration key of the matrix.
+// The built-in keys come first, followed by the GODEBUG keys and environment
+// variables, in alphabetical order.
+func (m Matrix) Dimensions() []Config {
+	var dims []Config
+	add := func(dim Config) {
+		if len(dim) > 0 {
+			dims = append(dims, dim)
+		}
+	}
+
+	var dim Config
+	for _, v := range m.Gcddtrace {
+		dim = append(dim, gcddtrace(v))
+	}
+	add(dim)
+	dim = nil
+	for _, v := range m.Gcdetectdeadlocks {
+		dim = append(dim, deadlockDetection(v))
+	}
+	add(dim)
+	dim = nil
+	for _, v := range m.GOMAXPROCS {
+		dim = append(dim, maxProcs(v))
+	}
+	add(dim)
+	dim = nil
+	for _, v := range m.Gcstoptheworld {
+		dim = append(dim, gcstoptheworld(v))
+	}
+	add(dim)
+	dim = nil
+	for _, v := range m.GOGC {
+		dim = append(dim, gogc(v))
+	}
+	add(dim)
+	dim = nil
+	for _, v := range m.GOMEMLIMIT {
+		dim = append(dim, gomemlimit(v))
+	}
+	add(dim)
+	dim = nil
+	for _, v := range m.Build {
+		dim = append(dim, buildMode(v))
+	}
+	add(dim)
+
+	for _, key := range sortedKeys(m.GODEBUG) {
+		dim = nil
+		for _, v := range m.GODEBUG[key] {
+			dim = append(dim, godebugValue{key, v})
+		}
+		add(dim)
+	}
+	for _, key := range sortedKeys(m.Env) {
+		dim = nil
+		for _, v := range m.Env[key] {
+			dim = append(dim, envValue{key, v})
+		}
+		add(dim)
+	}
+	return dims
+}
+
+// Validate checks that every value of the matrix is valid for its key.
+func (m Matrix) Validate() error {
+	for _, v := range m.Gcddtrace {
+		if v < int(gcddtraceOff) || v > int(gcddtraceTarget) {
+			return fmt.Errorf("invalid gcddtrace value: %d", v)
+		}
+	}
+	for _, v := range m.Gcdetectdeadlocks {
+		if v < int(deadlockDetectionOff) || v > int(deadlockDetectionMonitor) {
+			return fmt.Errorf("invalid gcdetectdeadlocks value: %d", v)
+		}
+	}
+	for _, v := range m.GOMAXPROCS {
+		if v < 1 {
+			return fmt.Errorf("invalid GOMAXPROCS value: %d", v)
+		}
+	}
+	for _, v := range m.Gcstoptheworld {
+		if v < int(gcstoptheworldOff) || v > int(gcstoptheworldSweep) {
+			return fmt.Errorf("invalid gcstoptheworld value: %d", v)
+		}
+	}
+	for _, v := range m.GOGC {
+		if !gogc(v).valid() {
+			return fmt.Errorf("invalid GOGC value: %q", v)
+		}
+	}
+	for _, v := range m.GOMEMLIMIT {
+		if !gomemlimit(v).valid() {
+			return fmt.Errorf("invalid GOMEMLIMIT value: %q", v)
+		}
+	}
+	for _, v := range m.Build {
+		switch buildMode(v) {
+		case buildPlain, buildRace, buildNoOpt:
+		default:
+			return fmt.Errorf("invalid build mode: %q", v)
+		}
+	}
+	for key, vs := range m.GODEBUG {
+		switch key {
+		case "gctrace":
+			return fmt.Errorf("GODEBUG key %q is set by the harness", key)
+		case "gcddtrace", "gcdetectdeadlocks", "gcstoptheworld":
+			return fmt.Errorf("GODEBUG key %q must be set as %q", key, key)
+		}
+		if key == "" || strings.ContainsAny(key, "=, ") {
+			return fmt.Errorf("invalid GODEBUG key: %q", key)
+		}
+		for _, v := range vs {
+			if strings.ContainsAny(v, "=, ") {
+				return fmt.Errorf("invalid value of GODEBUG key %s: %q", key, v)
+			}
+		}
+	}
+	for key := range m.Env {
+		switch key {
+		case "GODEBUG":
+			return fmt.Errorf("environment variable %s must be set with the GODEBUG keys", key)
+		case "GOMAXPROCS", "GOGC", "GOMEMLIMIT":
+			return fmt.Errorf("environment variable %s must be set as %q", key, key)
+		}
+		if key == "" || strings.ContainsAny(key, "= ") {
+			return fmt.Errorf("invalid environment variable: %q", key)
+		}
+	}
+	return nil
+}
+
+// Load overrides the keys of the matrix defined in the given file.
+// The file format is given by its extension: JSON, YAML or TOML.
+func (m *Matrix) Load(file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	switch ext := filepath.Ext(file); ext {
+	case ".json":
+		err = json.Unmarshal(data, m)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, m)
+	case ".toml":
+		err = toml.Unmarshal(data, m)
+	default:
+		return fmt.Errorf("unrecognized matrix file format: %q", ext)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	return nil
+}
+
+// Set overrides a key of the matrix with an assignment `KEY=VALUE,...`.
+// Keys are either built-in, i.e., `GOMAXPROCS`, `gcdetectdeadlocks`, `gcddtrace`,
+// `gcstoptheworld`, `GOGC`, `GOMEMLIMIT` or `build`, or are GODEBUG keys prefixed with
+// `GODEBUG.`, or environment variables prefixed with `env.`. Assigning no values removes the key from the configurations.
+func (m *Matrix) Set(assignment string) error {
+	key, value, ok := strings.Cut(assignment, "=")
+	if !ok {
+		return fmt.Errorf("assignment %q is not of the form KEY=VALUE,...", assignment)
+	}
+	var values []string
+	if value != "" {
+		values = strings.Split(value, ",")
+	}
+
+	ints := func() ([]int, error) {
+		var is []int
+		for _, v := range values {
+			i, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			is = append(is, i)
+		}
+		return is, nil
+	}
+
+	var err error
+	switch {
+	case key == "gcddtrace":
+		m.Gcddtrace, err = ints()
+	case key == "gcdetectdeadlocks":
+		m.Gcdetectdeadlocks, err = ints()
+	case key == "GOMAXPROCS":
+		m.GOMAXPROCS, err = ints()
+	case key == "gcstoptheworld":
+		m.Gcstoptheworld, err = ints()
+	case key == "GOGC":
+		m.GOGC = values
+	case key == "GOMEMLIMIT":
+		m.GOMEMLIMIT = values
+	case key == "build":
+		m.Build = values
+	case strings.HasPrefix(key, "GODEBUG."):
+		m.GODEBUG = setValues(m.GODEBUG, strings.TrimPrefix(key, "GODEBUG."), values)
+	case strings.HasPrefix(key, "env."):
+		m.Env = setValues(m.Env, strings.TrimPrefix(key, "env."), values)
+	default:
+		err = fmt.Errorf("unrecognized matrix key: %q", key)
+	}
+	return err
+}
+
+// setValues assigns the values to the key, or deletes it if there are none.
+func setValues(m map[string]matrixValues, key string, values []string) map[string]matrixValues {
+	if len(values) == 0 {
+		delete(m, key)
+		return m
+	}
+	if m == nil {
+		m = make(map[string]matrixValues)
+	}
+	m[key] = values
+	return m
+}
+
+// sortedKeys returns the keys of the map in alphabetical order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// matrixAssignments collects the `-set` command line flags.
+type matrixAssignments []string
+
+func (a *matrixAssignments) String() string {
+	return strings.Join(*a, " ")
+}
+
+func (a *matrixAssignments) Set(assignment string) error {
+	*a = append(*a, assignment)
+	return nil
+}
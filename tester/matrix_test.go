@@ -0,0 +1,120 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatrixLoad(t *testing.T) {
+	files := map[string]string{
+		"matrix.json": `{"GOMAXPROCS": [3, 16], "gcdetectdeadlocks": [2], "GOGC": [50, "off"], "GODEBUG": {"asyncpreemptoff": [0, "1"]}, "env": {"GOTRACEBACK": ["all"]}}`,
+		"matrix.yaml": "GOMAXPROCS: [3, 16]\ngcdetectdeadlocks: [2]\nGOGC: [50, off]\nGODEBUG:\n  asyncpreemptoff: [0, \"1\"]\nenv:\n  GOTRACEBACK: [all]\n",
+		"matrix.toml": "GOMAXPROCS = [3, 16]\ngcdetectdeadlocks = [2]\nGOGC = [50, \"off\"]\n[GODEBUG]\nasyncpreemptoff = [0, \"1\"]\n[env]\nGOTRACEBACK = [\"all\"]\n",
+	}
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), name)
+			require.NoError(t, os.WriteFile(file, []byte(content), 0o644))
+
+			m := Matrix{Gcddtrace: []int{0}, GOMAXPROCS: []int{1}}
+			require.NoError(t, m.Load(file))
+			require.NoError(t, m.Validate())
+			require.Equal(t, Matrix{
+				Gcddtrace:         []int{0},
+				Gcdetectdeadlocks: []int{2},
+				GOMAXPROCS:        []int{3, 16},
+				GOGC:              matrixValues{"50", "off"},
+				GODEBUG:           map[string]matrixValues{"asyncpreemptoff": {"0", "1"}},
+				Env:               map[string]matrixValues{"GOTRACEBACK": {"all"}},
+			}, m)
+		})
+	}
+
+	require.Error(t, (&Matrix{}).Load("matrix.ini"))
+}
+
+func TestMatrixSet(t *testing.T) {
+	m := Matrix{GOMAXPROCS: []int{1}}
+	require.NoError(t, m.Set("GOMAXPROCS=2,32"))
+	require.NoError(t, m.Set("gcdetectdeadlocks=0,2"))
+	require.NoError(t, m.Set("GODEBUG.asyncpreemptoff=1"))
+	require.NoError(t, m.Set("env.GOGC=off,100"))
+	require.NoError(t, m.Set("env.GOMEMLIMIT=1GiB"))
+	require.NoError(t, m.Set("env.GOMEMLIMIT="))
+	require.NoError(t, m.Set("gcstoptheworld=0,1,2"))
+	require.NoError(t, m.Set("GOGC=25,off"))
+	require.NoError(t, m.Set("GOMEMLIMIT=64MiB"))
+	require.NoError(t, m.Set("build=plain,race"))
+	require.Equal(t, Matrix{
+		Gcdetectdeadlocks: []int{0, 2},
+		GOMAXPROCS:        []int{2, 32},
+		Gcstoptheworld:    []int{0, 1, 2},
+		GOGC:              matrixValues{"25", "off"},
+		GOMEMLIMIT:        matrixValues{"64MiB"},
+		Build:             []string{"plain", "race"},
+		GODEBUG:           map[string]matrixValues{"asyncpreemptoff": {"1"}},
+		Env:               map[string]matrixValues{"GOGC": {"off", "100"}},
+	}, m)
+
+	require.Error(t, m.Set("GOMAXPROCS"))
+	require.Error(t, m.Set("GOMAXPROCS=x"))
+	require.Error(t, m.Set("foo=1"))
+	require.Error(t, m.Set("gcstoptheworld=mark"))
+}
+
+func TestMatrixValidate(t *testing.T) {
+	for _, m := range []Matrix{
+		{GOMAXPROCS: []int{0}},
+		{Gcdetectdeadlocks: []int{3}},
+		{Gcddtrace: []int{-1}},
+		{Gcstoptheworld: []int{3}},
+		{GOGC: matrixValues{"-50"}},
+		{GOMEMLIMIT: matrixValues{"1GB"}},
+		{Build: []string{"msan"}},
+		{GODEBUG: map[string]matrixValues{"gcstoptheworld": {"1"}}},
+		{Env: map[string]matrixValues{"GOGC": {"50"}}},
+		{GODEBUG: map[string]matrixValues{"gctrace": {"2"}}},
+		{GODEBUG: map[string]matrixValues{"gcdetectdeadlocks": {"1"}}},
+		{GODEBUG: map[string]matrixValues{"a": {"1,b=2"}}},
+		{Env: map[string]matrixValues{"GODEBUG": {"x=1"}}},
+		{Env: map[string]matrixValues{"GOMAXPROCS": {"1"}}},
+	} {
+		require.Error(t, m.Validate(), "%+v", m)
+	}
+	require.NoError(t, matrix.Validate())
+}
+
+func TestMatrixConfigurations(t *testing.T) {
+	m := Matrix{
+		Gcdetectdeadlocks: []int{1},
+		GOMAXPROCS:        []int{3},
+		Gcstoptheworld:    []int{0, 1},
+		GOGC:              matrixValues{"50"},
+		GOMEMLIMIT:        matrixValues{"off", "64MiB"},
+		GODEBUG:           map[string]matrixValues{"asyncpreemptoff": {"1"}},
+		Env:               map[string]matrixValues{"GOTRACEBACK": {"all"}},
+	}
+	dims := m.Dimensions()
+	require.Len(t, dims, 7)
+
+	c := Config{dims[0][0], dims[1][0], dims[2][1], dims[3][0], dims[4][1], dims[5][0], dims[6][0]}
+	require.Equal(t, "gcdetectdeadlocks-1-GOMAXPROCS-3-gcstoptheworld-1-GOGC-50-GOMEMLIMIT-64MiB-asyncpreemptoff-1-GOTRACEBACK-all", c.Name())
+	require.Equal(t, []string{
+		"GOMAXPROCS=3",
+		"GOGC=50",
+		"GOMEMLIMIT=64MiB",
+		"GOTRACEBACK=all",
+		"GODEBUG=gctrace=1,gcdetectdeadlocks=1,gcstoptheworld=1,asyncpreemptoff=1",
+	}, c.Flags())
+
+	v, ok := c.Value("gcstoptheworld")
+	require.True(t, ok)
+	require.Equal(t, "1", v)
+	v, ok = c.Value("GOMEMLIMIT")
+	require.True(t, ok)
+	require.Equal(t, "64MiB", v)
+	require.Equal(t, 3, c.Ps())
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"go/version"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// metricsEnv is the environment variable that gives instrumented targets
+	// the file to write their runtime metrics to.
+	metricsEnv = "GOLF_METRICS_FILE"
+	// metricsSuffix is appended to the trace file of a run to name its metrics file.
+	metricsSuffix = ".metrics.json"
+	// metricsHelperFile is the name of the helper injected into the directory of targets.
+	metricsHelperFile = "zz_golf_metrics"
+)
+
+// metricsHelper is the source of the helper injected into instrumented targets,
+// given their package name. It dumps every runtime metric as JSON when the
+// program exits, unless it crashes. Infinite bucket boundaries of histograms
+// are replaced by the adjacent finite boundary, as JSON has no infinities.
+//
+// The helper registers a runtime exit hook, which is only reachable by
+// toolchains older than Go 1.23, such as Golf's.
+const metricsHelper = `// Code generated by the golf tester. DO NOT EDIT.
+
+package %s
+
+import (
+	golfjson "encoding/json"
+	golfmath "math"
+	golfos "os"
+	golfmetrics "runtime/metrics"
+	_ "unsafe"
+)
+
+//go:linkname golfAddExitHook runtime.addExitHook
+func golfAddExitHook(f func(), runOnNonZeroExit bool)
+
+func init() {
+	golfAddExitHook(golfDumpMetrics, true)
+}
+
+func golfDumpMetrics() {
+	file := golfos.Getenv("` + metricsEnv + `")
+	if file == "" {
+		return
+	}
+	descs := golfmetrics.All()
+	samples := make([]golfmetrics.Sample, len(descs))
+	for i := range samples {
+		samples[i].Name = descs[i].Name
+	}
+	golfmetrics.Read(samples)
+
+	values := make(map[string]any, len(samples))
+	for _, s := range samples {
+		switch s.Value.Kind() {
+		case golfmetrics.KindUint64:
+			values[s.Name] = s.Value.Uint64()
+		case golfmetrics.KindFloat64:
+			values[s.Name] = s.Value.Float64()
+		case golfmetrics.KindFloat64Histogram:
+			h := s.Value.Float64Histogram()
+			buckets := append([]float64(nil), h.Buckets...)
+			if n := len(buckets); n > 1 && golfmath.IsInf(buckets[0], -1) {
+				buckets[0] = buckets[1]
+			}
+			if n := len(buckets); n > 1 && golfmath.IsInf(buckets[n-1], 1) {
+				buckets[n-1] = buckets[n-2]
+			}
+			values[s.Name] = map[string]any{"counts": h.Counts, "buckets": buckets}
+		}
+	}
+	if data, err := golfjson.Marshal(values); err == nil {
+		golfos.WriteFile(file, data, 0o644)
+	}
+}
+`
+
+// RuntimeMetrics holds the runtime/metrics of a run of an instrumented target at exit.
+type RuntimeMetrics struct {
+	// Values holds the scalar metrics, by name, e.g., "/sched/goroutines:goroutines".
+	Values map[string]float64 `json:"values"`
+	// Histograms holds the distribution metrics, by name, e.g., "/sched/pauses/total/gc:seconds".
+	Histograms map[string]Histogram `json:"histograms"`
+}
+
+// Histogram is a distribution metric. Counts[i] counts the samples
+// between Buckets[i] and Buckets[i+1].
+type Histogram struct {
+	Counts  []uint64  `json:"counts"`
+	Buckets []float64 `json:"buckets"`
+}
+
+// Quantile returns the midpoint of the bucket of the q-quantile of the
+// samples of the histogram, or NaN if it has no samples.
+func (h Histogram) Quantile(q float64) float64 {
+	var total uint64
+	for _, c := range h.Counts {
+		total += c
+	}
+	if total == 0 || len(h.Buckets) != len(h.Counts)+1 {
+		return math.NaN()
+	}
+	rank := uint64(math.Ceil(q * float64(total)))
+	var seen uint64
+	for i, c := range h.Counts {
+		if seen += c; seen >= max(rank, 1) {
+			return (h.Buckets[i] + h.Buckets[i+1]) / 2
+		}
+	}
+	return h.Buckets[len(h.Buckets)-1]
+}
+
+// Max returns the upper bound of the highest non-empty bucket of the
+// histogram, which bounds its samples, or NaN if it has no samples. As
+// instrumented targets replace the +Inf bound of the last bucket with its
+// lower bound, samples in that bucket may exceed it.
+func (h Histogram) Max() float64 {
+	if len(h.Buckets) != len(h.Counts)+1 {
+		return math.NaN()
+	}
+	for i := len(h.Counts) - 1; i >= 0; i-- {
+		if h.Counts[i] > 0 {
+			return h.Buckets[i+1]
+		}
+	}
+	return math.NaN()
+}
+
+// runtimeMetric is a measurement of a run, from its runtime metrics.
+type runtimeMetric struct {
+	name, unit string
+	get        func(*RuntimeMetrics) (float64, bool)
+}
+
+// runtimeMetrics are the measurements of runs reported from their runtime
+// metrics, i.e., the CPU time spent by the GC, the distribution of GC pauses,
+// the live heap, and the goroutines left at exit.
+var runtimeMetrics = []runtimeMetric{
+	{"GC CPU", "μs", metricValue("/cpu/classes/gc/total:cpu-seconds", 1e6)},
+	{"GC pause CPU", "μs", metricValue("/cpu/classes/gc/pause:cpu-seconds", 1e6)},
+	{"Median GC pause", "μs", metricHistogram("/sched/pauses/total/gc:seconds", 1e6, func(h Histogram) float64 { return h.Quantile(0.5) })},
+	{"Max GC pause upper bound", "μs", metricHistogram("/sched/pauses/total/gc:seconds", 1e6, Histogram.Max)},
+	{"Live heap", "B", metricValue("/gc/heap/live:bytes", 1)},
+	{"Goroutines", "goroutines", metricValue("/sched/goroutines:goroutines", 1)},
+}
+
+// metricValue returns the value of a scalar metric, in the given unit.
+func metricValue(name string, unit float64) func(*RuntimeMetrics) (float64, bool) {
+	return func(m *RuntimeMetrics) (float64, bool) {
+		v, ok := m.Values[name]
+		return v * unit, ok
+	}
+}
+
+// metricHistogram returns a statistic of a distribution metric, in the given unit.
+func metricHistogram(name string, unit float64, stat func(Histogram) float64) func(*RuntimeMetrics) (float64, bool) {
+	return func(m *RuntimeMetrics) (float64, bool) {
+		h, ok := m.Histograms[name]
+		if !ok {
+			return 0, false
+		}
+		v := stat(h)
+		return v * unit, !math.IsNaN(v)
+	}
+}
+
+// ReadRuntimeMetrics reads the metrics file written by an instrumented target.
+func ReadRuntimeMetrics(file string) (*RuntimeMetrics, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	m := &RuntimeMetrics{
+		Values:     make(map[string]float64),
+		Histograms: make(map[string]Histogram),
+	}
+	for name, value := range raw {
+		if strings.HasPrefix(strings.TrimSpace(string(value)), "{") {
+			var h Histogram
+			if err := json.Unmarshal(value, &h); err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", file, name, err)
+			}
+			m.Histograms[name] = h
+			continue
+		}
+		var v float64
+		if err := json.Unmarshal(value, &v); err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", file, name, err)
+		}
+		m.Values[name] = v
+	}
+	return m, nil
+}
+
+// metricsOverlay writes the build overlay that injects the metrics helper
+// into the target, and returns its path. The overlay is stored in the cache
+// directory, at a path addressed by its contents. If the Go command is too
+// recent for the helper, it returns an empty path.
+func (tc *targetCache) metricsOverlay(ctx context.Context, t target, gocmd string) (string, error) {
+	v, err := tc.goVersion(ctx, gocmd)
+	if err != nil {
+		return "", err
+	}
+	if version.IsValid(v) && version.Compare(v, "go1.23") >= 0 {
+		return "", nil
+	}
+
+	dir, err := filepath.Abs(t.Dir)
+	if err != nil {
+		return "", err
+	}
+	pkg, file := "main", metricsHelperFile+".go"
+	if t.Test {
+		// The helper joins the package of the tests, which may be an external test package.
+		tests, err := filepath.Glob(filepath.Join(dir, "*_test.go"))
+		if err != nil || len(tests) == 0 {
+			return "", fmt.Errorf("no test files in %s", t.Dir)
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), tests[0], nil, parser.PackageClauseOnly)
+		if err != nil {
+			return "", err
+		}
+		pkg, file = f.Name.Name, metricsHelperFile+"_test.go"
+	}
+
+	helper := fmt.Sprintf(metricsHelper, pkg)
+	h := sha256.Sum256([]byte(dir + "\x00" + helper))
+	overlayDir := filepath.Join(tc.Dir, "overlays", hex.EncodeToString(h[:]))
+	overlay := filepath.Join(overlayDir, "overlay.json")
+	if _, err := os.Stat(overlay); err == nil {
+		return overlay, nil
+	}
+
+	if err := os.MkdirAll(overlayDir, os.ModePerm); err != nil {
+		return "", err
+	}
+	helperPath := filepath.Join(overlayDir, file)
+	if err := os.WriteFile(helperPath, []byte(helper), 0o644); err != nil {
+		return "", err
+	}
+	content, err := json.Marshal(map[string]map[string]string{
+		"Replace": {filepath.Join(dir, file): helperPath},
+	})
+	if err != nil {
+		return "", err
+	}
+	// Write the overlay last, as other invocations may share the cache.
+	tmp := fmt.Sprintf("%s.%d.tmp", overlay, os.Getpid())
+	if err := os.WriteFile(tmp, content, 0o644); err != nil {
+		return "", err
+	}
+	return overlay, os.Rename(tmp, overlay)
+}
+
+// goVersion returns the Go version of the Go command, e.g., "go1.22.5".
+func (tc *targetCache) goVersion(ctx context.Context, gocmd string) (string, error) {
+	if v, ok := tc.versions[gocmd]; ok {
+		return v, nil
+	}
+	cmd := exec.CommandContext(ctx, gocmd, "env", "GOVERSION")
+	cmd.Dir = os.TempDir()
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s env GOVERSION: %w", gocmd, err)
+	}
+	v := strings.TrimSpace(string(out))
+	if version.IsValid(v) && version.Compare(v, "go1.23") >= 0 {
+		fmt.Println(gocmd, "is", v+": runtime metrics are only collected with toolchains older than go1.23")
+	}
+
+	if tc.versions == nil {
+		tc.versions = make(map[string]string)
+	}
+	tc.versions[gocmd] = v
+	return v, nil
+}
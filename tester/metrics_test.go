@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistogramQuantile(t *testing.T) {
+	h := Histogram{
+		Counts:  []uint64{1, 0, 3},
+		Buckets: []float64{0, 2, 4, 6},
+	}
+	require.Equal(t, 1.0, h.Quantile(0))
+	require.Equal(t, 1.0, h.Quantile(0.25))
+	require.Equal(t, 5.0, h.Quantile(0.5))
+	require.Equal(t, 5.0, h.Quantile(1))
+
+	require.True(t, math.IsNaN(Histogram{Counts: []uint64{0}, Buckets: []float64{0, 1}}.Quantile(0.5)))
+}
+
+func TestHistogramMax(t *testing.T) {
+	h := Histogram{
+		Counts:  []uint64{1, 3, 0},
+		Buckets: []float64{0, 2, 4, 6},
+	}
+	// The upper bound of the highest non-empty bucket, not its midpoint.
+	require.Equal(t, 4.0, h.Max())
+	require.True(t, math.IsNaN(Histogram{Counts: []uint64{0}, Buckets: []float64{0, 1}}.Max()))
+}
+
+func TestReadRuntimeMetrics(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "run"+metricsSuffix)
+	require.NoError(t, os.WriteFile(file, []byte(`{
+		"/sched/goroutines:goroutines": 3,
+		"/cpu/classes/gc/total:cpu-seconds": 0.25,
+		"/sched/pauses/total/gc:seconds": {"counts": [2, 1], "buckets": [0, 0.001, 0.002]}
+	}`), 0o644))
+
+	m, err := ReadRuntimeMetrics(file)
+	require.NoError(t, err)
+	require.Equal(t, map[string]float64{
+		"/sched/goroutines:goroutines":      3,
+		"/cpu/classes/gc/total:cpu-seconds": 0.25,
+	}, m.Values)
+	require.Equal(t, Histogram{
+		Counts:  []uint64{2, 1},
+		Buckets: []float64{0, 0.001, 0.002},
+	}, m.Histograms["/sched/pauses/total/gc:seconds"])
+
+	for _, metric := range runtimeMetrics {
+		v, ok := metric.get(m)
+		switch metric.name {
+		case "GC CPU":
+			require.True(t, ok)
+			require.Equal(t, 250000.0, v)
+		case "Median GC pause":
+			require.True(t, ok)
+			require.InDelta(t, 500.0, v, 1e-9)
+		case "Live heap":
+			require.False(t, ok)
+		}
+	}
+
+	_, err = ReadRuntimeMetrics(filepath.Join(t.TempDir(), "none"))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestMetricsOverlay(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pkg_test.go"), []byte("package pkg_test\n"), 0o644))
+
+	// The helper is injected by toolchains older than Go 1.23.
+	tc := &targetCache{Dir: t.TempDir(), versions: map[string]string{"go": "go1.22.5", "go-new": "go1.24.0"}}
+	overlay, err := tc.metricsOverlay(context.Background(), target{Dir: dir, Test: true}, "go")
+	require.NoError(t, err)
+	require.FileExists(t, overlay)
+
+	content, err := os.ReadFile(overlay)
+	require.NoError(t, err)
+	var o struct{ Replace map[string]string }
+	require.NoError(t, json.Unmarshal(content, &o))
+	helper, ok := o.Replace[filepath.Join(dir, metricsHelperFile+"_test.go")]
+	require.True(t, ok)
+	source, err := os.ReadFile(helper)
+	require.NoError(t, err)
+	require.Contains(t, string(source), "package pkg_test\n")
+
+	// Overlays are reused.
+	again, err := tc.metricsOverlay(context.Background(), target{Dir: dir, Test: true}, "go")
+	require.NoError(t, err)
+	require.Equal(t, overlay, again)
+
+	overlay, err = tc.metricsOverlay(context.Background(), target{Dir: dir, Test: true}, "go-new")
+	require.NoError(t, err)
+	require.Empty(t, overlay)
+}
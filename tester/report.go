@@ -17,6 +17,9 @@ type Report struct {
 	sync.Mutex
 	ticket  chan struct{}
 	Results map[string]*TargetReport
+	// Unvalidated holds the reports of runs with deadlock detection enabled
+	// of targets without deadlock annotations, which are not validated.
+	Unvalidated map[string]*TargetReport
 }
 
 func (r *Report) Append(report TargetReport) {
@@ -28,6 +31,17 @@ func (r *Report) Append(report TargetReport) {
 	r.Results[report.TraceFile] = &report
 }
 
+// AppendUnvalidated adds the report of a run that is not validated
+// against deadlock annotations.
+func (r *Report) AppendUnvalidated(report TargetReport) {
+	defer r.Unlock()
+	r.Lock()
+	if r.Unvalidated == nil {
+		r.Unvalidated = make(map[string]*TargetReport)
+	}
+	r.Unvalidated[report.TraceFile] = &report
+}
+
 // TargetReport is a struct that holds a report of a single target execution.
 type TargetReport struct {
 	Config
@@ -39,6 +53,8 @@ type TargetReport struct {
 	Trace    Trace
 	RawTrace []byte
 	Diff     *DeadlockDifferential
+	// Metrics holds the runtime metrics of the run, if the target was instrumented.
+	Metrics *RuntimeMetrics
 }
 
 // GetDeadlockToggleReport retrieves the name of the report for the
@@ -78,6 +94,24 @@ func (r *TargetReport) CompareWithTrace() {
 	r.Diff = &diff
 }
 
+// Comments lists the inconsistencies between the annotations of the target,
+// the directory it is placed in, and the trace.
+func (r *TargetReport) Comments() []string {
+	var comments []string
+	annotationsExpectDeadlock := r.DeadlockShouldBeFound()
+
+	if r.IsCorrect() && annotationsExpectDeadlock {
+		comments = append(comments, "Annotations expected deadlock in correct example")
+	}
+	if r.IsCorrect() && len(r.Trace.Deadlocks) > 0 {
+		comments = append(comments, "Deadlock found in correct example trace")
+	}
+	if r.IsDeadlock() && !annotationsExpectDeadlock {
+		comments = append(comments, "Missing deadlock annotation in deadlock example")
+	}
+	return comments
+}
+
 func (r *TargetReport) String() string {
 	const (
 		REPEAT = iota
@@ -98,16 +132,8 @@ func (r *TargetReport) String() string {
 		COMMENT:    "",
 	})
 
-	annotationsExpectDeadlock := r.DeadlockShouldBeFound()
-
-	if r.IsCorrect() && annotationsExpectDeadlock {
-		content[0][COMMENT] = "Annotations expected deadlock in correct example; "
-	}
-	if r.IsCorrect() && len(r.Trace.Deadlocks) > 0 {
-		content[0][COMMENT] += "Deadlock found in correct example trace; "
-	}
-	if r.IsDeadlock() && !annotationsExpectDeadlock {
-		content[0][COMMENT] += "Missing deadlock annotation in deadlock example; "
+	for _, comment := range r.Comments() {
+		content[0][COMMENT] += comment + "; "
 	}
 	if content[0][COMMENT] == "" {
 		content[0][COMMENT] = "-"
@@ -127,7 +153,7 @@ func (r *TargetReport) String() string {
 		})
 		mismatches := make([]DeadlockMismatch, 0, len(r.Diff.Mismatches))
 		for _, mismatch := range r.Diff.Mismatches {
-			if mismatch.Unexpected {
+			if mismatch.Unexpected || mismatch.WrongWaitReason {
 				mismatches = append(mismatches, mismatch)
 			}
 		}
@@ -135,9 +161,6 @@ func (r *TargetReport) String() string {
 		if len(mismatches) > 0 {
 			content[0][DEADLOCKS] = mismatches[0].String()
 			for i, mismatch := range mismatches[1:] {
-				if !mismatch.Unexpected {
-					continue
-				}
 				content = append(content, make([]string, COMMENT+1))
 				content[i+1][DEADLOCKS] = mismatch.String()
 				content[i+1][REPEAT] = strconv.Itoa(r.Repeat)
@@ -246,18 +269,103 @@ func (r *Report) String() string {
 	return content
 }
 
+// BuildModes returns the build modes of the results, in alphabetical order.
+func (r *Report) BuildModes() []buildMode {
+	var modes []buildMode
+	for _, report := range r.Results {
+		if !slices.Contains(modes, report.Config.BuildMode()) {
+			modes = append(modes, report.Config.BuildMode())
+		}
+	}
+	slices.Sort(modes)
+	return modes
+}
+
+// ForBuildMode returns the results of targets built in the given mode.
+func (r *Report) ForBuildMode(mode buildMode) *Report {
+	r2 := &Report{Results: make(map[string]*TargetReport)}
+	for k, report := range r.Results {
+		if report.Config.BuildMode() == mode {
+			r2.Results[k] = report
+		}
+	}
+	for k, report := range r.Unvalidated {
+		if report.Config.BuildMode() == mode {
+			if r2.Unvalidated == nil {
+				r2.Unvalidated = make(map[string]*TargetReport)
+			}
+			r2.Unvalidated[k] = report
+		}
+	}
+	return r2
+}
+
 // Tabulated produces a tabulated report of the analysis.
+// Results of different build modes are tabulated separately.
 func (r *Report) Tabulated() string {
-	type tabEntry struct {
-		target  string
-		goro    string
-		pconfig []int
-		total   float64
+	modes := r.BuildModes()
+	if len(modes) <= 1 {
+		return r.tabulated()
 	}
 
+	tables := make([]string, 0, len(modes))
+	for _, mode := range modes {
+		tables = append(tables, "Build mode: "+string(mode)+"\n"+r.ForBuildMode(mode).tabulated())
+	}
+	return strings.Join(tables, "\n\n")
+}
+
+// tabEntry counts the detections of the deadlocks expected by an annotation.
+type tabEntry struct {
+	target string
+	// goro is the position of the annotation, in the form file:line.
+	goro string
+	file string
+	line int
+	// pconfig counts the runs that found the expected deadlocks,
+	// and expected the runs that expected deadlocks, per GOMAXPROCS
+	// value. Annotations guarded by a configuration may only expect
+	// deadlocks for some of the values.
+	pconfig  []int
+	expected []int
+	total    float64
+	// runs lists the runs that expected the deadlocks.
+	runs []tabRun
+}
+
+// tabRun is a run that expected the deadlocks of an annotation,
+// and whether it found them.
+type tabRun struct {
+	report *TargetReport
+	found  bool
+}
+
+// found returns the number of runs that found the expected deadlocks,
+// and the number of runs that expected them, across GOMAXPROCS values.
+func (e tabEntry) found() (found, expected int) {
+	for i, p := range e.pconfig {
+		found += p
+		expected += e.expected[i]
+	}
+	return found, expected
+}
+
+// tabEntries counts the detections of the deadlocks expected by every annotation,
+// by position (file:line, since a target may have several files), in the runs
+// with deadlock detection. It also returns the
+// index of every GOMAXPROCS value of the runs in the counts.
+func (r *Report) tabEntries() (map[int]int, map[string]tabEntry) {
+	// Columns are the GOMAXPROCS values of the runs with deadlock detection.
+	var procs []int
+	for _, report := range r.Results {
+		if report.HasDeadlockDetection() && !slices.Contains(procs, report.Config.Ps()) {
+			procs = append(procs, report.Config.Ps())
+		}
+	}
+	slices.Sort(procs)
 	procconfigs := make(map[int]int)
-	for i, v := range defaultvalues[PROCS] {
-		procconfigs[int(v.(maxProcs))] = i
+	for i, p := range procs {
+		procconfigs[p] = i
 	}
 
 	entries := make(map[string]tabEntry)
@@ -265,6 +373,9 @@ func (r *Report) Tabulated() string {
 		if !report.HasDeadlockDetection() {
 			continue
 		}
+		if report.Diff == nil {
+			report.CompareWithTrace()
+		}
 
 	DEADLOCKS:
 		for _, dl := range report.Deadlocks {
@@ -272,43 +383,64 @@ func (r *Report) Tabulated() string {
 				// Skip `deadlocks: {0, false}` annotations.
 				continue
 			}
-			pos := fmt.Sprintf("%s:%d", report.Target, dl.Line)
+			pos := fmt.Sprintf("%s:%d", dl.Filename, dl.Line)
 			entry, ok := entries[pos]
 			if !ok {
 				entry = tabEntry{
-					target:  report.Target,
-					goro:    pos,
-					pconfig: make([]int, len(procconfigs)),
+					target:   report.Target,
+					goro:     pos,
+					file:     dl.Filename,
+					line:     dl.Line,
+					pconfig:  make([]int, len(procconfigs)),
+					expected: make([]int, len(procconfigs)),
 				}
 			}
+			entry.expected[procconfigs[report.Config.Ps()]]++
 
 			for _, mismatch := range report.Diff.Mismatches {
-				if dl.Line == mismatch.Line {
+				if dl.Filename == mismatch.Filename && dl.Line == mismatch.Line {
+					entry.runs = append(entry.runs, tabRun{report, false})
 					entries[pos] = entry
 					continue DEADLOCKS
 				}
 			}
 
 			entry.pconfig[procconfigs[report.Config.Ps()]]++
+			entry.runs = append(entry.runs, tabRun{report, true})
 			entries[pos] = entry
 		}
 	}
+	return procconfigs, entries
+}
+
+// tabulated produces a tabulated report of the analysis, regardless of build modes.
+// Detection rates are given with their 95% Wilson score intervals.
+func (r *Report) tabulated() string {
+	procconfigs, entries := r.tabEntries()
 
 	entriesSlice := make([]tabEntry, 0, len(entries))
 	correctTargets := make(map[string]struct{})
+	// remainingFound and remainingExpected count the runs of the annotations
+	// whose deadlocks were always found.
+	var remainingFound, remainingExpected int
 	aggregated := tabEntry{
-		pconfig: make([]int, len(procconfigs)),
+		pconfig:  make([]int, len(procconfigs)),
+		expected: make([]int, len(procconfigs)),
 	}
 
 	for _, entry := range entries {
-		var total float64
+		var total, expected float64
 		for i, p := range entry.pconfig {
 			total += float64(p)
+			expected += float64(entry.expected[i])
 			aggregated.pconfig[i] += p
+			aggregated.expected[i] += entry.expected[i]
 		}
-		total = total / (float64(len(procconfigs) * numberOfRepeats)) * 100
+		total = total / expected * 100
 		if total == 100 {
 			correctTargets[entry.target] = struct{}{}
+			remainingFound += int(expected)
+			remainingExpected += int(expected)
 			continue
 		}
 		entry.total = total
@@ -319,110 +451,282 @@ func (r *Report) Tabulated() string {
 		return strings.Compare(e1.goro, e2.goro)
 	})
 
-	content := make([]string, 1, len(entries))
+	content := make([]string, 1, len(entriesSlice)+3)
 
-	header := make([]string, len(procconfigs)+2)
+	header := make([]string, len(procconfigs)+3)
 	header[0] = "Benchmark"
 	for p, i := range procconfigs {
 		header[i+1] = strconv.Itoa(p) + "P"
+		if p < 0 {
+			// GOMAXPROCS is not part of the configurations.
+			header[i+1] = "default"
+		}
 	}
-	header[len(header)-1] = "Total"
+	header[len(header)-2] = "Total"
+	header[len(header)-1] = "95% CI"
 	content[0] = strings.Join(header, "\t")
 
 	for _, entry := range entriesSlice {
-		tabulated := make([]string, len(procconfigs)+2)
+		tabulated := make([]string, len(procconfigs)+3)
 		prettyTarget := strings.TrimPrefix(entry.goro, "tests/deadlock/")
 		prettyTarget = strings.TrimPrefix(prettyTarget, "gobench/")
 		prettyTarget = strings.Replace(prettyTarget, "blocking/", "", 1)
 		tabulated[0] = prettyTarget
 		for i, p := range entry.pconfig {
 			tabulated[i+1] = strconv.Itoa(p)
+			if entry.expected[i] == 0 {
+				// No deadlocks are expected for this GOMAXPROCS value.
+				tabulated[i+1] = "-"
+			}
 		}
-		tabulated[len(tabulated)-1] = strconv.FormatFloat(entry.total, 'f', 2, 64) + "%"
+		tabulated[len(tabulated)-2] = strconv.FormatFloat(entry.total, 'f', 2, 64) + "%"
+		tabulated[len(tabulated)-1] = formatInterval(entry.found())
 		content = append(content, strings.Join(tabulated, "\t"))
 	}
 
-	remainingTabulated := make([]string, len(procconfigs)+2)
+	remainingTabulated := make([]string, len(procconfigs)+3)
 	remainingTabulated[0] = fmt.Sprintf("Remaining %d go instruction (%d benchmarks)", len(entries)-len(entriesSlice), len(correctTargets))
-	remainingTabulated[len(remainingTabulated)-1] = strconv.FormatFloat(100, 'f', 2, 64) + "%"
+	remainingTabulated[len(remainingTabulated)-2] = strconv.FormatFloat(100, 'f', 2, 64) + "%"
+	remainingTabulated[len(remainingTabulated)-1] = formatInterval(remainingFound, remainingExpected)
 	content = append(content, strings.Join(remainingTabulated, "\t"))
 
-	aggregatedTabulated, aggregatedtotal := make([]string, len(procconfigs)+2), float64(0)
+	aggregatedTabulated, aggregatedtotal, aggregatedexpected := make([]string, len(procconfigs)+3), float64(0), float64(0)
 	aggregatedTabulated[0] = "Aggregated"
 	for i, p := range aggregated.pconfig {
-		aggregatedTabulated[i+1] = strconv.FormatFloat(float64(p)/float64(len(entries)*numberOfRepeats)*100, 'f', 2, 64) + "%"
+		aggregatedTabulated[i+1] = strconv.FormatFloat(float64(p)/float64(aggregated.expected[i])*100, 'f', 2, 64) + "%"
+		if aggregated.expected[i] == 0 {
+			aggregatedTabulated[i+1] = "-"
+		}
 		aggregatedtotal += float64(p)
+		aggregatedexpected += float64(aggregated.expected[i])
 	}
-	aggregatedTabulated[len(aggregatedTabulated)-1] = strconv.FormatFloat(aggregatedtotal/float64(len(entries)*numberOfRepeats*len(procconfigs))*100, 'f', 2, 64) + "%"
+	aggregatedTabulated[len(aggregatedTabulated)-2] = strconv.FormatFloat(aggregatedtotal/aggregatedexpected*100, 'f', 2, 64) + "%"
+	aggregatedTabulated[len(aggregatedTabulated)-1] = formatInterval(aggregated.found())
 	content = append(content, strings.Join(aggregatedTabulated, "\t"))
 
 	return strings.Join(content, "\n")
 }
 
-// OverheadMeasurements produces a report comparing the performance
-// of the GC with deadlock enabled and disabled at equivalent runtime configurations.
-func (r *Report) OverheadMeasurements() string {
-	reportSlice := make([]*TargetReport, 0, len(r.Results))
-	for _, report := range r.Results {
-		if report.Config.HasDeadlockDetection() {
-			reportSlice = append(reportSlice, report)
-		}
+// formatInterval formats the 95% Wilson score interval of a detection rate,
+// given the number of runs that found the expected deadlocks out of the runs
+// that expected them.
+func formatInterval(found, expected int) string {
+	if expected == 0 {
+		return "-"
 	}
+	lo, hi := wilsonInterval(found, expected)
+	return fmt.Sprintf("[%.2f%%, %.2f%%]", lo*100, hi*100)
+}
 
-	slices.SortFunc(reportSlice, func(r1, r2 *TargetReport) int {
-		return strings.Compare(r1.TraceFile, r2.TraceFile)
-	})
+// Races produces a report comparing the data races found in targets built
+// with the race detector, with deadlock detection enabled and disabled.
+// For every target, it lists the runs that reported data races under either.
+// Deadlock detection should not affect the results of the race detector.
+func (r *Report) Races() string {
+	type raceEntry struct {
+		runs, racyOff, racyOn int
+	}
 
-	const (
-		TARGET = iota
-		GCYCLES
-		MARKCLOCKOFF
-		MARKCLOCKON
-		CPUTILOFF
-		CPUTILON
-		TERMCLOCK
-		MARKCPU
-		TERMCPU
-		HEAP
-		STACK
-		UTILIZATION
-		NUMGOS
-	)
+	entries := make(map[string]*raceEntry)
+	for _, dlOffReport := range r.Results {
+		if dlOffReport.Config.BuildMode() != buildRace || dlOffReport.HasDeadlockDetection() {
+			continue
+		}
+		// Runs of targets without deadlock annotations are not validated,
+		// but their data races are compared.
+		report, ok := r.Results[dlOffReport.GetDeadlockToggleReport()]
+		if !ok {
+			if report, ok = r.Unvalidated[dlOffReport.GetDeadlockToggleReport()]; !ok {
+				continue
+			}
+		}
+		target := report.ExpectedDeadlocks.Target
+		entry, ok := entries[target]
+		if !ok {
+			entry = &raceEntry{}
+			entries[target] = entry
+		}
+		entry.runs++
+		if dlOffReport.Trace.Races > 0 {
+			entry.racyOff++
+		}
+		if report.Trace.Races > 0 {
+			entry.racyOn++
+		}
+	}
 
-	content := make([][]string, 1, 2)
-	content[0] = []string{
-		TARGET:       "Target",
-		GCYCLES:      "GC cycles",
-		MARKCLOCKOFF: "Mark clock OFF (μs)",
-		MARKCLOCKON:  "Mark clock ON (μs)",
-		CPUTILOFF:    "CPU utilization OFF (%)",
-		CPUTILON:     "CPU utilization ON (%)",
+	targets := make([]string, 0, len(entries))
+	for target := range entries {
+		targets = append(targets, target)
 	}
+	slices.Sort(targets)
+
+	content := make([]string, 1, len(targets)+1)
+	content[0] = strings.Join([]string{"Target", "Runs", "Racy runs OFF", "Racy runs ON"}, "\t")
+	for _, target := range targets {
+		entry := entries[target]
+		content = append(content, strings.Join([]string{
+			target,
+			strconv.Itoa(entry.runs),
+			strconv.Itoa(entry.racyOff),
+			strconv.Itoa(entry.racyOn),
+		}, "\t"))
+	}
+	return strings.Join(content, "\n")
+}
 
-	for _, report := range reportSlice {
+// OverheadMeasurements produces a CSV report comparing the performance of
+// the GC with deadlock detection enabled and disabled, for every target and
+// runtime configuration, across repeat rounds.
+//
+// Wall-clock and CPU times of the mark phase and of mark termination, where
+// deadlocks are detected, are measured per GC cycle, in microseconds. Their
+// medians across the cycles of every round are given with 95% bootstrap
+// intervals. The medians of the runs with deadlock detection enabled and
+// disabled are compared with the Mann–Whitney U test since, as for the
+// bootstrap, the cycles of a run are not independent of each other.
+// Intervals and p-values need at least 2 runs, i.e., -repeats 2, and are
+// given as "-" otherwise.
+// If targets were instrumented, the measurements of their runtime metrics
+// follow, once per run.
+func (r *Report) OverheadMeasurements() string {
+	// runs holds the reports of the runs with deadlock detection enabled and
+	// disabled, for a target and configuration, across repeat rounds.
+	type runs struct {
+		target, config string
+		on, off        []*TargetReport
+	}
+
+	groups := make(map[string]*runs)
+	for _, report := range r.Results {
+		if !report.Config.HasDeadlockDetection() {
+			continue
+		}
 		dlOffReport, ok := r.Results[report.GetDeadlockToggleReport()]
 		if !ok {
 			log.Println("Did not find equivalent report with deadlock detection off for:", report.TraceFile)
 			continue
 		}
-
-		onPerf := report.Trace.GetGCPerf()
-		if onPerf == (GCPerf{}) {
+		if len(report.Trace.GCMessages) == 0 || len(dlOffReport.Trace.GCMessages) == 0 {
 			// Missing GC trace?
 			log.Println("Missing GC trace for:", report.TraceFile)
 			continue
 		}
-		offPerf := dlOffReport.Trace.GetGCPerf()
-		perfDelta := GetPerfDelta(offPerf, onPerf)
-
-		content = append(content, []string{
-			TARGET:       report.TraceFile,
-			GCYCLES:      strconv.Itoa(perfDelta.gcCycles),
-			MARKCLOCKOFF: strconv.FormatFloat(perfDelta.avgMarkCPUOff, 'f', 2, 64),
-			MARKCLOCKON:  strconv.FormatFloat(perfDelta.avgMarkCPUOn, 'f', 2, 64),
-			CPUTILOFF:    strconv.FormatFloat(perfDelta.avgUtilizationOff, 'f', 2, 64),
-			CPUTILON:     strconv.FormatFloat(perfDelta.avgUtilizationOn, 'f', 2, 64),
-		})
+
+		key := report.Target + "\x00" + report.Config.Name()
+		group, ok := groups[key]
+		if !ok {
+			group = &runs{target: report.Target, config: report.Config.Name()}
+			groups[key] = group
+		}
+		group.on = append(group.on, report)
+		group.off = append(group.off, dlOffReport)
+	}
+
+	keys := sortedKeys(groups)
+
+	// Runtime metrics are only reported if targets were instrumented.
+	var hasMetrics bool
+	for _, group := range groups {
+		for _, report := range group.on {
+			hasMetrics = hasMetrics || report.Metrics != nil
+		}
+	}
+
+	header := []string{"Target", "Configuration", "Runs", "GC cycles OFF", "GC cycles ON", "CPU utilization OFF (%)", "CPU utilization ON (%)"}
+	columns := func(name, unit string) {
+		header = append(header,
+			name+" OFF ("+unit+")", name+" OFF CI low ("+unit+")", name+" OFF CI high ("+unit+")",
+			name+" ON ("+unit+")", name+" ON CI low ("+unit+")", name+" ON CI high ("+unit+")",
+			name+" p-value")
+	}
+	for _, metric := range gcMetrics {
+		columns(metric.name, "μs")
+	}
+	if hasMetrics {
+		for _, metric := range runtimeMetrics {
+			columns(metric.name, metric.unit)
+		}
+	}
+	content := make([][]string, 1, len(keys)+1)
+	content[0] = header
+
+	format := func(x float64) string {
+		return strconv.FormatFloat(x, 'f', 2, 64)
+	}
+	for _, key := range keys {
+		group := groups[key]
+		// samples collects the values of a metric per GC cycle, for every run.
+		samples := func(reports []*TargetReport, get func(gcTrace) float64) (perRun [][]float64, all []float64) {
+			for _, report := range reports {
+				var run []float64
+				for _, gc := range report.Trace.GCMessages {
+					run = append(run, get(gc))
+				}
+				perRun = append(perRun, run)
+				all = append(all, run...)
+			}
+			return perRun, all
+		}
+
+		_, offUtilization := samples(group.off, func(gc gcTrace) float64 { return gc.cpuUtilization })
+		_, onUtilization := samples(group.on, func(gc gcTrace) float64 { return gc.cpuUtilization })
+
+		row := []string{
+			group.target,
+			group.config,
+			strconv.Itoa(len(group.on)),
+			strconv.Itoa(len(offUtilization)),
+			strconv.Itoa(len(onUtilization)),
+			format(mean(offUtilization)),
+			format(mean(onUtilization)),
+		}
+		warned := false
+		compare := func(offRuns, onRuns [][]float64, off, on []float64) {
+			offMedians, onMedians := runMedians(offRuns), runMedians(onRuns)
+			if len(offMedians) < 2 || len(onMedians) < 2 {
+				// A single run gives neither intervals nor p-values.
+				if !warned {
+					log.Println("Fewer than 2 runs for intervals and p-values of:", group.target, group.config)
+					warned = true
+				}
+				row = append(row, format(median(off)), "-", "-", format(median(on)), "-", "-", "-")
+				return
+			}
+			offLo, offHi := bootstrapMedianInterval(offRuns)
+			onLo, onHi := bootstrapMedianInterval(onRuns)
+			p := mannWhitneyTest(offMedians, onMedians)
+			row = append(row,
+				format(median(off)), format(offLo), format(offHi),
+				format(median(on)), format(onLo), format(onHi),
+				strconv.FormatFloat(p, 'f', 4, 64))
+		}
+		for _, metric := range gcMetrics {
+			offRuns, off := samples(group.off, metric.get)
+			onRuns, on := samples(group.on, metric.get)
+			compare(offRuns, onRuns, off, on)
+		}
+		if hasMetrics {
+			// Runtime metrics are measured once per run.
+			runSamples := func(reports []*TargetReport, get func(*RuntimeMetrics) (float64, bool)) (perRun [][]float64, all []float64) {
+				for _, report := range reports {
+					if report.Metrics == nil {
+						continue
+					}
+					if v, ok := get(report.Metrics); ok {
+						perRun = append(perRun, []float64{v})
+						all = append(all, v)
+					}
+				}
+				return perRun, all
+			}
+			for _, metric := range runtimeMetrics {
+				offRuns, off := runSamples(group.off, metric.get)
+				onRuns, on := runSamples(group.on, metric.get)
+				compare(offRuns, onRuns, off, on)
+			}
+		}
+		content = append(content, row)
 	}
 
 	lines := make([]string, 0, len(content))
@@ -523,11 +827,11 @@ func (r *Report) OverheadMeasurementsPlot() string {
 `
 }
 
-// OverheadMeasurementsBoxplot produces a boxplot comparing the performance
-// of the GC with deadlock enabled and disabled at equivalent runtime configurations.
-// The output is a .tex file that can be compiled to a PDF, and resembles the evaluation
-// in the paper.
-func (r *Report) OverheadMeasurementsBoxplot() string {
+// slowdowns computes the performance deltas of the GC between the runs with
+// deadlock detection enabled and their equivalent runs with deadlock detection
+// disabled, for correct and deadlocking targets, sorted by the slowdown of the
+// average mark CPU time.
+func (r *Report) slowdowns() (perfsCorrect, perfsDeadlock []GCPerf) {
 	reportSlice := make([]*TargetReport, 0, len(r.Results))
 	for _, report := range r.Results {
 		if report.HasDeadlockDetection() {
@@ -536,7 +840,7 @@ func (r *Report) OverheadMeasurementsBoxplot() string {
 	}
 
 	// Compute performance deltas
-	perfsCorrect, perfsDeadlock := make([]GCPerf, 0, len(reportSlice)), make([]GCPerf, 0, len(reportSlice))
+	perfsCorrect, perfsDeadlock = make([]GCPerf, 0, len(reportSlice)), make([]GCPerf, 0, len(reportSlice))
 	for _, report := range reportSlice {
 		dlOffReport, ok := r.Results[report.GetDeadlockToggleReport()]
 		if !ok {
@@ -570,6 +874,15 @@ func (r *Report) OverheadMeasurementsBoxplot() string {
 		p2Norm := NormalizeSlowdown(p2.avgMarkCPUOff, p2.avgMarkCPUOn)
 		return int(p1Norm*100 - p2Norm*100)
 	})
+	return perfsCorrect, perfsDeadlock
+}
+
+// OverheadMeasurementsBoxplot produces a boxplot comparing the performance
+// of the GC with deadlock enabled and disabled at equivalent runtime configurations.
+// The output is a .tex file that can be compiled to a PDF, and resembles the evaluation
+// in the paper.
+func (r *Report) OverheadMeasurementsBoxplot() string {
+	perfsCorrect, perfsDeadlock := r.slowdowns()
 
 	// Normalize slowdowns
 	perfsCorrectDataLog, perfsDeadlockDataLog := make([]float64, 0, len(perfsCorrect)), make([]float64, 0, len(perfsDeadlock))
@@ -6,6 +6,7 @@ import (
 	"go/token"
 	"os"
 	"path"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -229,3 +230,218 @@ func TestReportString(t *testing.T) {
 	require.Equal(t, "Correct not deadlocks: 0/1", msg[5])
 	require.Equal(t, "Incorrect guesses: 1 (100.00%)", msg[6])
 }
+
+func TestReportTabulatedGuards(t *testing.T) {
+	defer func(n int) { numberOfRepeats = n }(numberOfRepeats)
+	numberOfRepeats = 1
+
+	expect := func(expr string) ExpectedDeadlocks {
+		return ExpectedDeadlocks{
+			Target: "foo",
+			Deadlocks: []ExpectedDeadlock{{
+				Position:   token.Position{Filename: "foo/main.go", Line: 10},
+				Expression: &ast.Ident{Name: expr},
+			}},
+		}
+	}
+
+	r := &Report{Results: map[string]*TargetReport{}}
+	for _, p := range matrix.GOMAXPROCS {
+		p := maxProcs(p)
+		report := &TargetReport{
+			Config:            Config{p, deadlockDetectionCollect},
+			ExpectedDeadlocks: expect("true"),
+			Diff:              &DeadlockDifferential{},
+		}
+		if p == maxProcs1 {
+			// A guarded annotation expects no deadlocks at 1P.
+			report.ExpectedDeadlocks = expect("false")
+		}
+		if p == maxProcs10 {
+			report.Diff.Mismatches = []DeadlockMismatch{{ExpectedDeadlock: report.Deadlocks[0]}}
+		}
+		r.Results[report.Config.Name()] = report
+	}
+
+	lines := strings.Split(r.Tabulated(), "\n")
+	require.Len(t, lines, 4)
+	require.Equal(t, "foo/main.go:10\t-\t1\t1\t0\t66.67%\t[20.77%, 93.85%]", lines[1])
+	require.Equal(t, "Aggregated\t-\t100.00%\t100.00%\t0.00%\t66.67%\t[20.77%, 93.85%]", lines[3])
+}
+
+func TestReportTabulatedFiles(t *testing.T) {
+	// Annotations on the same line of different files of a target are
+	// counted separately.
+	expected := func(file string) ExpectedDeadlock {
+		return ExpectedDeadlock{
+			Position:   token.Position{Filename: path.Join("foo", file), Line: 10},
+			Expression: &ast.Ident{Name: "true"},
+		}
+	}
+	report := &TargetReport{
+		Config: Config{maxProcs1, deadlockDetectionCollect},
+		ExpectedDeadlocks: ExpectedDeadlocks{
+			Target:    "foo",
+			Deadlocks: []ExpectedDeadlock{expected("a.go"), expected("b.go")},
+		},
+		Diff: &DeadlockDifferential{
+			Mismatches: []DeadlockMismatch{{ExpectedDeadlock: expected("b.go")}},
+		},
+	}
+	r := &Report{Results: map[string]*TargetReport{report.Config.Name(): report}}
+
+	lines := strings.Split(r.Tabulated(), "\n")
+	require.Len(t, lines, 4)
+	require.Equal(t, "foo/b.go:10\t0\t0.00%\t[0.00%, 79.35%]", lines[1])
+	// The deadlocks expected in a.go are always found.
+	require.True(t, strings.HasPrefix(lines[2], "Remaining 1 go instruction (1 benchmarks)\t"), lines[2])
+	require.True(t, strings.HasPrefix(lines[3], "Aggregated\t50.00%\t50.00%\t"), lines[3])
+}
+
+func TestReportTabulatedBuildModes(t *testing.T) {
+	r := &Report{Results: map[string]*TargetReport{}}
+	for _, mode := range []buildMode{buildPlain, buildRace} {
+		for _, dd := range []deadlockDetection{deadlockDetectionOff, deadlockDetectionCollect} {
+			report := &TargetReport{
+				Config: Config{dd, maxProcs1, mode},
+				ExpectedDeadlocks: ExpectedDeadlocks{
+					Target: "foo",
+					Deadlocks: []ExpectedDeadlock{{
+						Position:   token.Position{Filename: "foo/main.go", Line: 10},
+						Expression: &ast.Ident{Name: "true"},
+					}},
+				},
+				Diff: &DeadlockDifferential{},
+			}
+			if mode == buildRace {
+				report.Trace.Races = 1
+				if dd == deadlockDetectionCollect {
+					report.Diff.Mismatches = []DeadlockMismatch{{ExpectedDeadlock: report.Deadlocks[0]}}
+				}
+			}
+			report.TraceFile = report.Config.Name()
+			r.Results[report.TraceFile] = report
+		}
+	}
+
+	require.Equal(t, []buildMode{buildPlain, buildRace}, r.BuildModes())
+	tables := strings.Split(r.Tabulated(), "\n\n")
+	require.Len(t, tables, 2)
+	require.True(t, strings.HasPrefix(tables[0], "Build mode: plain\nBenchmark\t1P\tTotal\t95% CI\n"))
+	require.Contains(t, tables[0], "Aggregated\t100.00%\t100.00%")
+	require.True(t, strings.HasPrefix(tables[1], "Build mode: race\nBenchmark\t1P\tTotal\t95% CI\nfoo/main.go:10\t0\t0.00%\t[0.00%, 79.35%]\n"))
+
+	// Runs of targets without annotations are only kept to compare data races.
+	bar := Config{deadlockDetectionOff, maxProcs1, buildRace}
+	r.Append(TargetReport{Config: bar, TraceFile: "bar/" + bar.Name(), ExpectedDeadlocks: ExpectedDeadlocks{Target: "bar"}, Trace: Trace{Races: 1}})
+	bar = bar.WithToggledDeadlockDetection()
+	r.AppendUnvalidated(TargetReport{Config: bar, TraceFile: "bar/" + bar.Name(), ExpectedDeadlocks: ExpectedDeadlocks{Target: "bar"}})
+
+	require.Equal(t, "Target\tRuns\tRacy runs OFF\tRacy runs ON\nbar\t1\t1\t0\nfoo\t1\t1\t1", r.Races())
+
+	// A single build mode is tabulated as is.
+	require.Equal(t, r.ForBuildMode(buildPlain).tabulated(), r.ForBuildMode(buildPlain).Tabulated())
+}
+
+func TestReportOverheadMeasurements(t *testing.T) {
+	r := &Report{Results: map[string]*TargetReport{}}
+	for repeat := 1; repeat <= 2; repeat++ {
+		for _, dd := range []deadlockDetection{deadlockDetectionOff, deadlockDetectionCollect} {
+			c := Config{dd, maxProcs1}
+			report := &TargetReport{
+				Config:            c,
+				TraceFile:         path.Join("results-"+strconv.Itoa(repeat), "foo", c.Name()),
+				Repeat:            repeat,
+				ExpectedDeadlocks: ExpectedDeadlocks{Target: "foo"},
+			}
+			for cycle := 1; cycle <= 3; cycle++ {
+				// Deadlock detection doubles the time of mark termination.
+				term := float64(cycle)
+				if dd == deadlockDetectionCollect {
+					term *= 2
+				}
+				report.Trace.GCMessages = append(report.Trace.GCMessages, gcTrace{
+					cycle:          cycle,
+					cpuUtilization: 1,
+					timeUnit:       "ms",
+					clockMarkTime:  1,
+					clockTermTime:  term,
+					cpuMarkTime:    2,
+					cpuTermTime:    term,
+				})
+			}
+			r.Results[report.TraceFile] = report
+		}
+	}
+
+	lines := strings.Split(r.OverheadMeasurements(), "\n")
+	require.Len(t, lines, 2)
+	header, row := strings.Split(lines[0], ",\t"), strings.Split(lines[1], ",\t")
+	require.Len(t, row, len(header))
+	columns := make(map[string]string)
+	for i, name := range header {
+		columns[name] = row[i]
+	}
+
+	require.Equal(t, "foo", columns["Target"])
+	require.Equal(t, "gcdetectdeadlocks-1-GOMAXPROCS-1", columns["Configuration"])
+	require.Equal(t, "2", columns["Runs"])
+	require.Equal(t, "6", columns["GC cycles OFF"])
+	require.Equal(t, "1000.00", columns["Mark wall OFF (μs)"])
+	require.Equal(t, "1000.00", columns["Mark wall ON (μs)"])
+	require.Equal(t, "1.0000", columns["Mark wall p-value"])
+	require.Equal(t, "2000.00", columns["Mark CPU ON (μs)"])
+	require.Equal(t, "2000.00", columns["Mark termination wall OFF (μs)"])
+	require.Equal(t, "4000.00", columns["Mark termination wall ON (μs)"])
+	// Runs, not GC cycles, are compared, as the cycles of a run are not independent.
+	require.Equal(t, "0.3333", columns["Mark termination wall p-value"])
+	require.Equal(t, "4000.00", columns["Mark termination CPU ON (μs)"])
+	require.NotContains(t, columns, "GC CPU OFF (μs)")
+
+	// Runtime metrics are reported once per run, if targets were instrumented.
+	for _, report := range r.Results {
+		gcCPU := 0.001
+		if report.Config.HasDeadlockDetection() {
+			gcCPU *= 2
+		}
+		report.Metrics = &RuntimeMetrics{
+			Values: map[string]float64{
+				"/cpu/classes/gc/total:cpu-seconds": gcCPU,
+				"/sched/goroutines:goroutines":      float64(report.Repeat),
+			},
+		}
+	}
+	lines = strings.Split(r.OverheadMeasurements(), "\n")
+	require.Len(t, lines, 2)
+	header, row = strings.Split(lines[0], ",\t"), strings.Split(lines[1], ",\t")
+	require.Len(t, row, len(header))
+	columns = make(map[string]string)
+	for i, name := range header {
+		columns[name] = row[i]
+	}
+	require.Equal(t, "1000.00", columns["GC CPU OFF (μs)"])
+	require.Equal(t, "2000.00", columns["GC CPU ON (μs)"])
+	require.Equal(t, "1.50", columns["Goroutines ON (goroutines)"])
+	require.Equal(t, "1.00", columns["Goroutines ON CI low (goroutines)"])
+	require.Equal(t, "2.00", columns["Goroutines ON CI high (goroutines)"])
+	// Missing metrics are left out.
+	require.Equal(t, "0.00", columns["Live heap ON (B)"])
+
+	// A single run gives neither intervals nor p-values.
+	for file, report := range r.Results {
+		if report.Repeat == 2 {
+			delete(r.Results, file)
+		}
+	}
+	lines = strings.Split(r.OverheadMeasurements(), "\n")
+	require.Len(t, lines, 2)
+	header, row = strings.Split(lines[0], ",\t"), strings.Split(lines[1], ",\t")
+	columns = make(map[string]string)
+	for i, name := range header {
+		columns[name] = row[i]
+	}
+	require.Equal(t, "4000.00", columns["Mark termination wall ON (μs)"])
+	require.Equal(t, "-", columns["Mark termination wall ON CI low (μs)"])
+	require.Equal(t, "-", columns["Mark termination wall p-value"])
+	require.Equal(t, "-", columns["GC CPU p-value"])
+}
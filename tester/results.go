@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// resultsPrefix prefixes the result directory of every repeat round.
+const resultsPrefix = "results-"
+
+// ReanalyzeResults regenerates the reports of earlier runs from the traces in
+// the given result directories, or in every `results-<n>` directory in the
+// working directory if none are given. Traces are extracted again, and
+// annotations are parsed again from the current sources of the targets
+// under `-tests`.
+//
+// Configurations are identified by the names of the trace files, so only the
+// traces of configurations in the current matrix are reloaded. Annotations
+// are mapped to functions through the symbol tables of the targets,
+// compiled again, or found in the build cache. Exceptions are recovered from
+// the traces, except for exit statuses, which are not recorded.
+func ReanalyzeResults(dirs []string) {
+	writeReports(loadResults(dirs))
+}
+
+// CompareResults reports the changes in detection rates between two sets of
+// results, e.g., of runs with different builds of Golf, given the directories
+// holding the `results-<n>` directories of each set. Traces are reloaded as
+// by ReanalyzeResults.
+func CompareResults(sets []string) {
+	if len(sets) != 2 {
+		log.Fatal("Expected the directories of two result sets to compare, got ", len(sets))
+	}
+	reports := make([]*Report, 0, len(sets))
+	for _, set := range sets {
+		dirs, err := resultDirs(set)
+		if err != nil || len(dirs) == 0 {
+			log.Fatal("No result directories found in ", set)
+		}
+		reports = append(reports, loadResults(dirs))
+	}
+
+	content := reports[1].Compare(reports[0])
+	if reportDest == "" {
+		fmt.Println(content)
+		return
+	}
+	if err := os.WriteFile(reportDest, []byte(content), os.ModePerm); err != nil {
+		log.Fatal("Failed to write report:", err)
+	}
+}
+
+// resultDirs returns the `results-<n>` directories in the given directory.
+// Reports may be written next to them with the same prefix, e.g., `results-perf.csv`.
+func resultDirs(dir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, resultsPrefix+"*"))
+	if err != nil {
+		return nil, err
+	}
+	dirs := matches[:0]
+	for _, match := range matches {
+		if info, err := os.Stat(match); err == nil && info.IsDir() {
+			dirs = append(dirs, match)
+		}
+	}
+	return dirs, nil
+}
+
+// loadResults reloads the reports of the runs in the given result directories,
+// or in every `results-<n>` directory in the working directory if none are given.
+func loadResults(dirs []string) *Report {
+	if len(dirs) == 0 {
+		var err error
+		if dirs, err = resultDirs("."); err != nil {
+			log.Fatal("Failed to find result directories:", err)
+		}
+		if len(dirs) == 0 {
+			log.Fatal("No result directories found")
+		}
+	}
+	matchExamples := regexp.MustCompile(matchExamplesStr)
+	dontMatchExamples := regexp.MustCompile(dontMatchExamplesStr)
+
+	configs := make(map[string]Config)
+	for c := range EmitConfigurations() {
+		configs[c.Name()] = c
+	}
+
+	cacheDir := buildCache
+	if cacheDir == "" {
+		dir, err := os.MkdirTemp("", "golf-tester")
+		if err != nil {
+			log.Fatal("Failed to create build directory:", err)
+		}
+		defer os.RemoveAll(dir)
+		cacheDir = dir
+	}
+	compiledTargets := &targetCache{Dir: cacheDir}
+
+	fullReport := &Report{
+		ticket: make(chan struct{}, parallelism),
+	}
+	var skipped int
+	for _, dir := range dirs {
+		repeat, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(dir), resultsPrefix))
+		if err != nil || !strings.HasPrefix(filepath.Base(dir), resultsPrefix) {
+			log.Fatalf("Result directory %s is not named %s<round>", dir, resultsPrefix)
+		}
+
+		err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || strings.HasSuffix(p, metricsSuffix) {
+				return err
+			}
+			traceFile, timedOut := strings.CutSuffix(p, ".tmp")
+			if timedOut {
+				// Runs leave their output in a temporary file when they time out.
+				if _, err := os.Stat(traceFile); err == nil {
+					return nil
+				}
+			}
+
+			c, ok := configs[filepath.Base(traceFile)]
+			if !ok {
+				skipped++
+				return nil
+			}
+			rel, err := filepath.Rel(dir, filepath.Dir(traceFile))
+			if err != nil {
+				return err
+			}
+			t, test, ok := resultTarget(path.Join(testFiles, filepath.ToSlash(rel)))
+			if !ok {
+				fmt.Println("No target for trace:", p)
+				skipped++
+				return nil
+			}
+			// Only the directories of the targets are matched.
+			if dontMatchExamplesStr != "" && dontMatchExamples.MatchString(t.Dir) || !matchExamples.MatchString(t.Dir) {
+				return nil
+			}
+
+			report := TargetReport{
+				Config:    c,
+				Name:      test,
+				TraceFile: traceFile,
+				Repeat:    repeat,
+			}
+			fullReport.Add(1)
+			go func() {
+				defer func() {
+					<-fullReport.ticket
+					fullReport.Done()
+				}()
+				fullReport.ticket <- struct{}{}
+				reloadRun(fullReport, report, t, timedOut, compiledTargets)
+			}()
+			return nil
+		})
+		if err != nil {
+			log.Fatal("Failed to read results:", err)
+		}
+	}
+	fullReport.Wait()
+	if skipped > 0 {
+		fmt.Println("Skipped", skipped, "traces of configurations or targets outside the current matrix and tests")
+	}
+	return fullReport
+}
+
+// resultTarget returns the target whose results are in the given directory,
+// relative to the directory of the targets, and the Test function, for test
+// packages, which have a result directory per Test function.
+func resultTarget(dir string) (t target, test string, ok bool) {
+	if _, err := os.Stat(path.Join(dir, "main.go")); err == nil {
+		return target{Dir: dir, Metrics: collectMetrics}, "", true
+	}
+	pkg := path.Dir(dir)
+	if tests, _ := filepath.Glob(filepath.Join(pkg, "*_test.go")); len(tests) > 0 {
+		return target{Dir: pkg, Test: true, Metrics: collectMetrics}, path.Base(dir), true
+	}
+	return target{}, "", false
+}
+
+// reloadRun adds the report of an earlier run of the target, from its trace file.
+// As when they are run, the incomplete output of runs that timed out is not analyzed.
+func reloadRun(fullReport *Report, report TargetReport, t target, timedOut bool, compiledTargets *targetCache) {
+	gocmd := goCompiler
+	if perf && !report.Config.HasDeadlockDetection() {
+		gocmd = baselineCompiler
+	}
+	compiled := compiledTargets.compile(t, gocmd, report.Config.BuildFlags())
+
+	var err error
+	if report.ExpectedDeadlocks, err = t.expectations(report.Name, report.Config, compiled.Symbols); err != nil {
+		log.Fatal("Failed to get expected deadlocks:", err)
+	}
+	if report.Metrics, err = ReadRuntimeMetrics(report.TraceFile + metricsSuffix); err != nil && !os.IsNotExist(err) {
+		report.Exception = err
+	}
+
+	if timedOut {
+		report.Exception = errors.New("go runtime timed out")
+		fullReport.Append(report)
+		return
+	}
+
+	content, err := os.ReadFile(report.TraceFile)
+	if err != nil {
+		report.Exception = errors.New("Failed to read result file: " + report.TraceFile + ": " + err.Error())
+		fullReport.Append(report)
+		return
+	}
+	// Strip the header written by EmitToFile.
+	if header, trace, ok := bytes.Cut(content, []byte("\n\n")); ok && bytes.HasPrefix(header, []byte("Ran ")) {
+		content = trace
+	}
+	report.RawTrace = content
+	if compiled.Err != nil {
+		report.Exception = errors.New("compilation failure")
+	}
+
+	report.Trace, err = ExtractTrace(report.RawTrace)
+	report.Exception = errors.Join(report.Exception, err)
+
+	appendRun(fullReport, report)
+}
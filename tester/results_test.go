@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultTarget(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "prog"), os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "prog", "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "pkg"), os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pkg", "pkg_test.go"), []byte(targetTestFile), 0o644))
+
+	tgt, test, ok := resultTarget(filepath.Join(dir, "prog"))
+	require.True(t, ok)
+	require.Equal(t, target{Dir: filepath.Join(dir, "prog")}, tgt)
+	require.Empty(t, test)
+
+	tgt, test, ok = resultTarget(filepath.Join(dir, "pkg", "TestA"))
+	require.True(t, ok)
+	require.Equal(t, target{Dir: filepath.Join(dir, "pkg"), Test: true}, tgt)
+	require.Equal(t, "TestA", test)
+
+	_, _, ok = resultTarget(filepath.Join(dir, "none"))
+	require.False(t, ok)
+}
+
+func TestReloadRun(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/pkg\n\ngo 1.22\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pkg_test.go"), []byte(targetTestFile), 0o644))
+	tgt := target{Dir: dir, Test: true}
+	cache := &targetCache{Dir: t.TempDir()}
+
+	results := t.TempDir()
+	traceFile := filepath.Join(results, "gcdetectdeadlocks-1-GOMAXPROCS-1")
+	report := TargetReport{
+		Config:    Config{deadlockDetectionCollect, maxProcs1},
+		Name:      "TestA",
+		TraceFile: traceFile,
+		Repeat:    2,
+	}
+	report.ExpectedDeadlocks.Target = filepath.Join(dir, "TestA")
+	report.RawTrace = []byte("PASS\n")
+	require.NoError(t, report.EmitToFile())
+
+	r := &Report{}
+	reloadRun(r, report, tgt, false, cache)
+	require.Contains(t, r.Results, traceFile)
+	reloaded := r.Results[traceFile]
+	require.Equal(t, "PASS\n", string(reloaded.RawTrace))
+	require.Equal(t, 2, reloaded.Repeat)
+	require.Equal(t, filepath.Join(dir, "TestA"), reloaded.ExpectedDeadlocks.Target)
+	require.Len(t, reloaded.ExpectedDeadlocks.Deadlocks, 1)
+	require.Equal(t, "example.com/pkg.TestA.func1", reloaded.ExpectedDeadlocks.Deadlocks[0].FunctionName)
+	require.NoError(t, reloaded.Exception)
+
+	r = &Report{}
+	reloadRun(r, report, tgt, true, cache)
+	require.EqualError(t, r.Results[traceFile].Exception, "go runtime timed out")
+}
+
+func TestResultDirs(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "results-1"), os.ModePerm))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "results-2"), os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "results-perf.csv"), nil, 0o644))
+
+	dirs, err := resultDirs(dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dir, "results-1"), filepath.Join(dir, "results-2")}, dirs)
+}
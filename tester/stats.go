@@ -0,0 +1,261 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+)
+
+const (
+	// confidenceZ is the standard normal quantile of 95% confidence intervals.
+	confidenceZ = 1.959963984540054
+	// significanceLevel is the level at which differences between detection
+	// rates are reported as significant.
+	significanceLevel = 0.05
+	// bootstrapResamples is the number of resamples of bootstrap confidence intervals.
+	bootstrapResamples = 1000
+	// mannWhitneyExactLimit is the largest total size of the samples for which
+	// the Mann–Whitney U test uses the exact distribution of U.
+	mannWhitneyExactLimit = 40
+)
+
+// wilsonInterval returns the bounds of the 95% Wilson score interval of a
+// proportion, given the number of successes out of the number of trials.
+// Unlike the normal approximation, it stays within [0, 1], and is accurate
+// for rates close to 0 or 1, and for few trials.
+func wilsonInterval(successes, trials int) (lo, hi float64) {
+	if trials == 0 {
+		return 0, 1
+	}
+	n := float64(trials)
+	p := float64(successes) / n
+	z2 := confidenceZ * confidenceZ
+	center := (p + z2/(2*n)) / (1 + z2/n)
+	margin := confidenceZ / (1 + z2/n) * math.Sqrt(p*(1-p)/n+z2/(4*n*n))
+	return max(0, center-margin), min(1, center+margin)
+}
+
+// fisherExactTest returns the two-sided p-value of Fisher's exact test of the
+// difference between the proportions successes1/trials1 and successes2/trials2.
+// The p-value sums the probabilities of the tables with the same margins that
+// are at most as likely as the observed one.
+func fisherExactTest(successes1, trials1, successes2, trials2 int) float64 {
+	successes, trials := successes1+successes2, trials1+trials2
+	if trials1 == 0 || trials2 == 0 || successes == 0 || successes == trials {
+		return 1
+	}
+
+	lchoose := func(n, k int) float64 {
+		a, _ := math.Lgamma(float64(n + 1))
+		b, _ := math.Lgamma(float64(k + 1))
+		c, _ := math.Lgamma(float64(n - k + 1))
+		return a - b - c
+	}
+	// prob is the hypergeometric probability of x successes in the first sample.
+	prob := func(x int) float64 {
+		return math.Exp(lchoose(trials1, x) + lchoose(trials2, successes-x) - lchoose(trials, successes))
+	}
+
+	observed := prob(successes1)
+	var p float64
+	for x := max(0, successes-trials2); x <= min(successes, trials1); x++ {
+		// Allow for rounding errors when comparing with the observed table.
+		if px := prob(x); px <= observed*(1+1e-7) {
+			p += px
+		}
+	}
+	return min(1, p)
+}
+
+// holmAdjust adjusts the p-values of a family of tests with the Holm–Bonferroni
+// method, so that they may be compared with the significance level without
+// inflating the chance of any false positive.
+func holmAdjust(pvalues []float64) []float64 {
+	order := make([]int, len(pvalues))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(i, j int) int {
+		switch {
+		case pvalues[i] < pvalues[j]:
+			return -1
+		case pvalues[i] > pvalues[j]:
+			return 1
+		}
+		return 0
+	})
+
+	adjusted := make([]float64, len(pvalues))
+	var running float64
+	for rank, i := range order {
+		running = max(running, min(1, float64(len(pvalues)-rank)*pvalues[i]))
+		adjusted[i] = running
+	}
+	return adjusted
+}
+
+// mean returns the mean of the values, or 0 if there are none.
+func mean(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
+
+// median returns the median of the values, or 0 if there are none.
+func median(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	xs = slices.Clone(xs)
+	slices.Sort(xs)
+	if n := len(xs); n%2 == 0 {
+		return (xs[n/2-1] + xs[n/2]) / 2
+	}
+	return xs[len(xs)/2]
+}
+
+// runMedians returns the median of the values of every run with values.
+func runMedians(runs [][]float64) []float64 {
+	var medians []float64
+	for _, run := range runs {
+		if len(run) > 0 {
+			medians = append(medians, median(run))
+		}
+	}
+	return medians
+}
+
+// bootstrapMedianInterval returns the 95% percentile bootstrap interval of the
+// median of the values of several runs. Runs are resampled as a whole, as the
+// values of a run are not independent of each other. Resampling is seeded,
+// so that intervals are reproducible.
+func bootstrapMedianInterval(runs [][]float64) (lo, hi float64) {
+	if len(runs) == 0 {
+		return 0, 0
+	}
+	rng := rand.New(rand.NewSource(1))
+	medians := make([]float64, bootstrapResamples)
+	var sample []float64
+	for i := range medians {
+		sample = sample[:0]
+		for range runs {
+			sample = append(sample, runs[rng.Intn(len(runs))]...)
+		}
+		medians[i] = median(sample)
+	}
+	slices.Sort(medians)
+	return medians[bootstrapResamples*25/1000], medians[bootstrapResamples*975/1000-1]
+}
+
+// mannWhitneyTest returns the two-sided p-value of the Mann–Whitney U test of
+// whether the values of one sample tend to be larger than those of the other.
+// For small samples, it uses the exact distribution of U given the ranks of
+// the values, so ties are accounted for. Otherwise, it uses the normal
+// approximation of U, corrected for ties and continuity.
+func mannWhitneyTest(xs, ys []float64) float64 {
+	n1, n2 := float64(len(xs)), float64(len(ys))
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+
+	type value struct {
+		x     float64
+		first bool
+	}
+	values := make([]value, 0, len(xs)+len(ys))
+	for _, x := range xs {
+		values = append(values, value{x, true})
+	}
+	for _, y := range ys {
+		values = append(values, value{y, false})
+	}
+	slices.SortFunc(values, func(v1, v2 value) int {
+		switch {
+		case v1.x < v2.x:
+			return -1
+		case v1.x > v2.x:
+			return 1
+		}
+		return 0
+	})
+
+	// Tied values share the average of their ranks. Ranks are doubled
+	// in ranks2, so that they are integers.
+	var rankSum, ties float64
+	ranks2 := make([]int, 0, len(values))
+	for i := 0; i < len(values); {
+		j := i
+		for j < len(values) && values[j].x == values[i].x {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for _, v := range values[i:j] {
+			if v.first {
+				rankSum += rank
+			}
+			ranks2 = append(ranks2, i+j+1)
+		}
+		t := float64(j - i)
+		ties += t*t*t - t
+		i = j
+	}
+
+	if len(values) <= mannWhitneyExactLimit {
+		return mannWhitneyExactTest(ranks2, len(xs), int(2*rankSum))
+	}
+
+	u := rankSum - n1*(n1+1)/2
+	n := n1 + n2
+	variance := n1 * n2 / 12 * (n + 1 - ties/(n*(n-1)))
+	if variance <= 0 {
+		// All values are equal.
+		return 1
+	}
+	z := (math.Abs(u-n1*n2/2) - 0.5) / math.Sqrt(variance)
+	return min(1, math.Erfc(max(0, z)/math.Sqrt2))
+}
+
+// mannWhitneyExactTest returns the two-sided p-value of the Mann–Whitney U test,
+// given the doubled ranks of all values, the size of the first sample, and
+// the doubled sum of its ranks. The p-value is the share of the ways to pick
+// the ranks of the first sample whose sum is at least as far from its mean.
+func mannWhitneyExactTest(ranks2 []int, n1, rankSum2 int) float64 {
+	total := 0
+	for _, r := range ranks2 {
+		total += r
+	}
+	// counts[k][s] is the number of ways to pick k of the ranks seen so far,
+	// with a doubled sum of s.
+	counts := make([][]float64, n1+1)
+	for k := range counts {
+		counts[k] = make([]float64, total+1)
+	}
+	counts[0][0] = 1
+	for i, r := range ranks2 {
+		for k := min(i+1, n1); k > 0; k-- {
+			for s := total; s >= r; s-- {
+				counts[k][s] += counts[k-1][s-r]
+			}
+		}
+	}
+
+	// The doubled mean of the sum of the ranks of the first sample.
+	mean2 := n1 * total / len(ranks2)
+	dev := rankSum2 - mean2
+	if dev < 0 {
+		dev = -dev
+	}
+	var extreme, all float64
+	for s, c := range counts[n1] {
+		all += c
+		if d := s - mean2; d >= dev || -d >= dev {
+			extreme += c
+		}
+	}
+	return min(1, extreme/all)
+}
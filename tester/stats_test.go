@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWilsonInterval(t *testing.T) {
+	lo, hi := wilsonInterval(19, 20)
+	require.InDelta(t, 0.7639, lo, 1e-4)
+	require.InDelta(t, 0.9911, hi, 1e-4)
+
+	lo, hi = wilsonInterval(0, 5)
+	require.Equal(t, 0.0, lo)
+	require.InDelta(t, 0.4345, hi, 1e-4)
+
+	lo, hi = wilsonInterval(5, 5)
+	require.InDelta(t, 0.5655, lo, 1e-4)
+	require.Equal(t, 1.0, hi)
+}
+
+func TestFisherExactTest(t *testing.T) {
+	require.InDelta(t, 0.4857, fisherExactTest(3, 4, 1, 4), 1e-4)
+	require.InDelta(t, 0.0079, fisherExactTest(5, 5, 0, 5), 1e-4)
+	require.InDelta(t, 0.0197, fisherExactTest(19, 20, 12, 20), 1e-4)
+	require.Equal(t, 1.0, fisherExactTest(5, 5, 5, 5))
+	require.Equal(t, 1.0, fisherExactTest(0, 0, 3, 5))
+}
+
+func TestHolmAdjust(t *testing.T) {
+	require.InDeltaSlice(t, []float64{0.04, 0.06, 0.06, 0.5}, holmAdjust([]float64{0.01, 0.02, 0.03, 0.5}), 1e-9)
+	// Adjusted p-values keep the order of the p-values.
+	require.InDeltaSlice(t, []float64{0.03, 0.03, 0.03}, holmAdjust([]float64{0.02, 0.01, 0.011}), 1e-9)
+}
+
+func TestMedian(t *testing.T) {
+	require.Equal(t, 0.0, median(nil))
+	require.Equal(t, 2.0, median([]float64{3, 1, 2}))
+	require.Equal(t, 2.5, median([]float64{4, 1, 3, 2}))
+}
+
+func TestRunMedians(t *testing.T) {
+	require.Nil(t, runMedians(nil))
+	require.Equal(t, []float64{2, 1.5}, runMedians([][]float64{{3, 1, 2}, {}, {1, 2}}))
+}
+
+func TestBootstrapMedianInterval(t *testing.T) {
+	lo, hi := bootstrapMedianInterval([][]float64{{1, 2, 3}})
+	require.Equal(t, 2.0, lo)
+	require.Equal(t, 2.0, hi)
+
+	runs := [][]float64{{1, 1}, {2, 2}, {3, 3}, {4, 4}, {5, 5}}
+	lo, hi = bootstrapMedianInterval(runs)
+	require.Less(t, lo, 3.0)
+	require.Greater(t, hi, 3.0)
+	require.GreaterOrEqual(t, lo, 1.0)
+	require.LessOrEqual(t, hi, 5.0)
+	// Intervals are reproducible.
+	lo2, hi2 := bootstrapMedianInterval(runs)
+	require.Equal(t, lo, lo2)
+	require.Equal(t, hi, hi2)
+}
+
+func TestMannWhitneyTest(t *testing.T) {
+	// Small samples use the exact distribution of U: 2 of the 252 ways to
+	// split the ranks are as extreme.
+	require.InDelta(t, 2.0/252, mannWhitneyTest([]float64{1, 2, 3, 4, 5}, []float64{6, 7, 8, 9, 10}), 1e-9)
+	// With ties, 2 of the 6 ways to pick ranks {1.5, 1.5, 3.5, 3.5} are as extreme.
+	require.InDelta(t, 1.0/3, mannWhitneyTest([]float64{1, 1}, []float64{2, 2}), 1e-9)
+	require.Equal(t, 1.0, mannWhitneyTest([]float64{1}, []float64{2}))
+	// Larger samples use the normal approximation.
+	var xs, ys []float64
+	for i := 0; i < 25; i++ {
+		xs, ys = append(xs, float64(i)), append(ys, float64(i+25))
+	}
+	require.Less(t, mannWhitneyTest(xs, ys), 1e-8)
+	require.Greater(t, mannWhitneyTest(xs, xs), 0.9)
+	require.Equal(t, 1.0, mannWhitneyTest([]float64{1, 1}, []float64{1, 1, 1}))
+	require.Equal(t, 1.0, mannWhitneyTest(nil, []float64{1}))
+	require.Greater(t, mannWhitneyTest([]float64{1, 3, 5, 7}, []float64{2, 4, 6, 8}), 0.5)
+}
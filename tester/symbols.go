@@ -0,0 +1,190 @@
+package main
+
+import (
+	"debug/elf"
+	"debug/gosym"
+	"debug/macho"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	// Name component of function literals, relative to the enclosing function.
+	// Literals directly inside named functions are numbered "func1", "func2", etc.,
+	// while literals nested in other literals are numbered "1", "2", etc.
+	literalComponent = regexp.MustCompile(`^(func)?([0-9]+)$`)
+	// Name component of the wrappers the compiler generates for go statements
+	// that call functions with arguments, methods or built-in functions.
+	gowrapComponent = regexp.MustCompile(`^gowrap([0-9]+)$`)
+)
+
+// sourceLine is a line in a source file.
+type sourceLine struct {
+	file string
+	line int
+}
+
+// SymbolTable maps the source positions of the functions in a compiled binary
+// to their symbol names, which are the names the runtime prints for them in
+// tracebacks and deadlock reports.
+type SymbolTable struct {
+	// funcs maps each source line to the names of the functions whose
+	// entry point is at that line.
+	funcs map[sourceLine][]string
+}
+
+// ReadSymbolTable reads the symbol table of a Go binary from its pclntab.
+// ELF and Mach-O binaries are supported.
+func ReadSymbolTable(binary string) (*SymbolTable, error) {
+	pclntab, text, err := readPclntab(binary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read symbol table of %s: %v", binary, err)
+	}
+	table, err := gosym.NewTable(nil, gosym.NewLineTable(pclntab, text))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read symbol table of %s: %v", binary, err)
+	}
+
+	st := &SymbolTable{funcs: make(map[sourceLine][]string)}
+	for _, f := range table.Funcs {
+		file, line, _ := table.PCToLine(f.Entry)
+		if file == "" {
+			continue
+		}
+		l := sourceLine{file, line}
+		st.funcs[l] = append(st.funcs[l], f.Name)
+	}
+	return st, nil
+}
+
+// readPclntab returns the contents of the pclntab section of the binary,
+// and the address of its text section.
+func readPclntab(binary string) (pclntab []byte, text uint64, err error) {
+	if f, err := elf.Open(binary); err == nil {
+		defer f.Close()
+		pcln, txt := f.Section(".gopclntab"), f.Section(".text")
+		if pcln == nil || txt == nil {
+			return nil, 0, errors.New("missing .gopclntab or .text section")
+		}
+		data, err := pcln.Data()
+		return data, txt.Addr, err
+	}
+	if f, err := macho.Open(binary); err == nil {
+		defer f.Close()
+		pcln, txt := f.Section("__gopclntab"), f.Section("__text")
+		if pcln == nil || txt == nil {
+			return nil, 0, errors.New("missing __gopclntab or __text section")
+		}
+		data, err := pcln.Data()
+		return data, txt.Addr, err
+	}
+	return nil, 0, errors.New("unsupported executable format")
+}
+
+// Func returns the symbol name of the named function declared at the
+// given position. The name is not qualified by the package, e.g., "f" or
+// "(*T).m", and type parameters are ignored. It returns "" if the function
+// is not in the binary, e.g., because all calls to it were inlined.
+func (st *SymbolTable) Func(name, file string, line int) string {
+	if st == nil {
+		return ""
+	}
+	name = genericParam.ReplaceAllString(name, "")
+	for _, sym := range st.at(file, line) {
+		if _, local := splitQualifier(genericParam.ReplaceAllString(sym, "")); local == name {
+			return sym
+		}
+	}
+	return ""
+}
+
+// Package returns the package qualifier of the symbols of functions declared
+// in the given file, e.g., "main" or "example.com/pkg". It returns "" if
+// the binary has no functions from the file.
+func (st *SymbolTable) Package(file string) string {
+	if st == nil {
+		return ""
+	}
+	if abs, err := filepath.Abs(file); err == nil {
+		file = abs
+	}
+	for l, syms := range st.funcs {
+		if l.file == file {
+			pkg, _ := splitQualifier(syms[0])
+			return pkg
+		}
+	}
+	return ""
+}
+
+// splitQualifier splits a symbol name into the package path qualifying it
+// and the rest of the name. Dots in the last element of the package path are
+// escaped in symbol names, so the qualifier ends at the first dot after it.
+func splitQualifier(sym string) (pkg, local string) {
+	i := strings.LastIndex(sym, "/") + 1
+	if j := strings.Index(sym[i:], "."); j >= 0 {
+		return sym[:i+j], sym[i+j+1:]
+	}
+	return "", sym
+}
+
+// Literal returns the symbol name of the i'th function literal (counting
+// from 0) of the function with symbol name parent that starts at the given
+// line. It returns "" if there is no such literal in the binary, e.g.,
+// because it was inlined.
+func (st *SymbolTable) Literal(parent, file string, line, i int) string {
+	return st.child(parent, file, line, i, literalComponent)
+}
+
+// GoWrapper returns the symbol name of the wrapper function generated for
+// the i'th go statement (counting from 0) of the function with symbol name
+// parent that is at the given line. It returns "" if there is no such
+// wrapper, which is the case for go statements that call a function without
+// arguments.
+func (st *SymbolTable) GoWrapper(parent, file string, line, i int) string {
+	return st.child(parent, file, line, i, gowrapComponent)
+}
+
+// child returns the i'th symbol, in the order the compiler numbered
+// them, of the functions starting at the given line whose names consist
+// of parent followed by a single component matching re.
+func (st *SymbolTable) child(parent, file string, line, i int, re *regexp.Regexp) string {
+	if st == nil || parent == "" {
+		return ""
+	}
+	type numbered struct {
+		sym string
+		n   int
+	}
+	var children []numbered
+	for _, sym := range st.at(file, line) {
+		component, ok := strings.CutPrefix(sym, parent+".")
+		if !ok {
+			continue
+		}
+		m := re.FindStringSubmatch(component)
+		if m == nil {
+			continue
+		}
+		n, _ := strconv.Atoi(m[len(m)-1])
+		children = append(children, numbered{sym, n})
+	}
+	if i >= len(children) {
+		return ""
+	}
+	slices.SortFunc(children, func(a, b numbered) int { return a.n - b.n })
+	return children[i].sym
+}
+
+// at returns the symbol names of the functions starting at the given line.
+func (st *SymbolTable) at(file string, line int) []string {
+	if abs, err := filepath.Abs(file); err == nil {
+		file = abs
+	}
+	return st.funcs[sourceLine{file, line}]
+}
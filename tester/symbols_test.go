@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const symbolsProgram = `package main
+
+type W[T any] struct{ ch chan T }
+
+func (w *W[T]) Start() {
+	go func() {
+		<-w.ch // deadlocks: 1
+	}()
+}
+
+func f(x int) { select {} }
+
+func g() { select {} }
+
+func main() {
+	go func(x int) {
+		select {} // deadlocks: 1
+	}(1)
+	// deadlocks: 1
+	go f(1)
+	// deadlocks: 1
+	go g()
+	go func() {
+		go func() { select {} }() // deadlocks: 1
+	}()
+	(&W[int]{}).Start()
+}
+`
+
+func TestGetDeadlockExpectationsSymbols(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(symbolsProgram), 0o644))
+	build := exec.Command("go", "build", "-o", "main", "main.go")
+	build.Dir = dir
+	build.Env = append(os.Environ(), "GO111MODULE=off")
+	out, err := build.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	symbols, err := ReadSymbolTable(filepath.Join(dir, "main"))
+	require.NoError(t, err)
+
+	exp, err := getDeadlockExpectations(dir, Config{}, symbols)
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(exp.Deadlocks))
+	for _, dl := range exp.Deadlocks {
+		names = append(names, dl.FunctionName)
+	}
+	require.Equal(t, []string{
+		"main.(*W).Start.func1",
+		"main.main.gowrap1",
+		"main.main.gowrap2",
+		"main.g",
+		"main.main.func2.1",
+	}, names)
+}
+
+func TestReadSymbolTable(t *testing.T) {
+	_, err := ReadSymbolTable("symbols_test.go")
+	require.Error(t, err)
+}
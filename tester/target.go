@@ -0,0 +1,321 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// targetTimeout bounds the time to compile a target, and to run it.
+	targetTimeout = 200 * time.Second
+
+	// testBinary is the name of the executables of test packages.
+	testBinary = "golf.test"
+
+	// raceExitCode is the exit status of programs built with the
+	// race detector that reported data races.
+	raceExitCode = 66
+)
+
+// target is a program or a test package run by the harness.
+//
+// Programs are "main.go" files, built with `go build main.go` and run once
+// per configuration. Test packages are directories with "_test.go" files,
+// built with `go test -c`, whose Test functions are run separately per
+// configuration. Targets may have their own go.mod.
+type target struct {
+	// Dir is the directory of the target.
+	Dir string
+	// Test is true if the target is a test package.
+	Test bool
+	// Metrics is true if the target is built with a helper that writes its
+	// runtime metrics to the file given by GOLF_METRICS_FILE at exit.
+	Metrics bool
+}
+
+// compiledTarget is the outcome of compiling a target.
+type compiledTarget struct {
+	// Binary is the path of the executable.
+	Binary string
+	// Symbols is the symbol table of the binary, or nil if it could not be read.
+	Symbols *SymbolTable
+	// Tests lists the Test functions of a test package.
+	Tests []string
+
+	// Err is the compilation failure, if any, with the compiler output.
+	Err    error
+	Output []byte
+}
+
+// binaryName returns the file name of the executable of the target.
+func (t target) binaryName() string {
+	if t.Test {
+		return testBinary
+	}
+	return "main"
+}
+
+// compileCmd returns the command that compiles the target with the given Go command
+// and build flags into the given executable. The build flags of instrumented
+// targets include the overlay of the metrics helper.
+func (t target) compileCmd(ctx context.Context, gocmd, binary string, flags []string) *exec.Cmd {
+	args := append([]string{"build"}, flags...)
+	args = append(args, "-o", binary, "main.go")
+	if t.Metrics {
+		args = append(args, metricsHelperFile+".go")
+	}
+	if t.Test {
+		args = append([]string{"test", "-c"}, flags...)
+		if t.Metrics {
+			// go vet does not see the files of the overlay.
+			args = append(args, "-vet=off")
+		}
+		args = append(args, "-o", binary, ".")
+	}
+	cmd := exec.CommandContext(ctx, gocmd, args...)
+	cmd.Dir = t.Dir
+	return cmd
+}
+
+// runCmd returns the command that runs the executable of the target in its
+// directory. For test packages, only the given Test function is run.
+func (t target) runCmd(binary, test string) *exec.Cmd {
+	var args []string
+	if t.Test {
+		args = []string{"-test.run", "^" + regexp.QuoteMeta(test) + "$", "-test.count=1"}
+	}
+	cmd := exec.Command(binary, args...)
+	cmd.Dir = t.Dir
+	return cmd
+}
+
+// listTests lists the Test functions of a compiled test package.
+func (t target) listTests(binary string) ([]string, error) {
+	cmd := exec.Command(binary, "-test.list", "^Test")
+	cmd.Dir = t.Dir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	var tests []string
+	for _, line := range strings.Split(string(out), "\n") {
+		// The list also includes matching examples, benchmarks and fuzz tests.
+		if strings.HasPrefix(line, "Test") {
+			tests = append(tests, line)
+		}
+	}
+	return tests, nil
+}
+
+// expectations extracts the expected deadlocks of the target under the
+// given configuration. For test packages, only the annotations outside
+// Test functions other than the given one apply.
+func (t target) expectations(test string, c Config, symbols *SymbolTable) (ExpectedDeadlocks, error) {
+	expected, err := getDeadlockExpectations(t.Dir, c, symbols)
+	if err != nil {
+		return expected, err
+	}
+	if t.Test {
+		expected = expected.ForTest(test)
+		expected.Target = path.Join(t.Dir, test)
+	}
+	return expected, nil
+}
+
+// targetCache holds the targets compiled so far, by target, Go command and build flags.
+// Failed compilations are not cached, and are retried for every configuration.
+//
+// Binaries are stored in Dir, at paths derived from the Go toolchain, the
+// build command and the contents of the target, so that they are reused
+// across invocations of the harness until any of these change.
+type targetCache struct {
+	sync.Mutex
+	// Dir is the directory of the cached binaries.
+	Dir string
+
+	targets    map[targetKey]*compiledTarget
+	toolchains map[string]string
+	versions   map[string]string
+}
+
+// targetKey identifies a target compiled with a Go command and build flags.
+type targetKey struct {
+	target
+	gocmd string
+	flags string
+}
+
+// compile compiles the target with the given Go command and build flags,
+// unless it was already compiled. Annotations are mapped to functions through
+// the symbol table of the binary.
+func (tc *targetCache) compile(t target, gocmd string, flags []string) *compiledTarget {
+	tc.Lock()
+	defer tc.Unlock()
+	tk := targetKey{t, gocmd, strings.Join(flags, "\x00")}
+	if compiled, ok := tc.targets[tk]; ok {
+		return compiled
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), targetTimeout)
+	defer cancel()
+	if t.Metrics {
+		overlay, err := tc.metricsOverlay(ctx, t, gocmd)
+		if err != nil {
+			return &compiledTarget{Err: err, Output: []byte(err.Error() + "\n")}
+		}
+		if overlay == "" {
+			// The toolchain does not support the helper.
+			t.Metrics = false
+		} else {
+			flags = append(slices.Clip(flags), "-overlay", overlay)
+		}
+	}
+	key, err := tc.key(ctx, t, gocmd, flags)
+	if err != nil {
+		return &compiledTarget{Err: err, Output: []byte(err.Error() + "\n")}
+	}
+	compiled := &compiledTarget{Binary: filepath.Join(tc.Dir, key[:2], key, t.binaryName())}
+
+	if _, err := os.Stat(compiled.Binary); err != nil {
+		if err := os.MkdirAll(filepath.Dir(compiled.Binary), os.ModePerm); err != nil {
+			return &compiledTarget{Err: err, Output: []byte(err.Error() + "\n")}
+		}
+		// Build next to the cached binary, and only move it in place once complete,
+		// as other invocations may share the cache.
+		tmp := fmt.Sprintf("%s.%d.tmp", compiled.Binary, os.Getpid())
+		var out bytes.Buffer
+		cmd := t.compileCmd(ctx, gocmd, tmp, flags)
+		cmd.Stdout, cmd.Stderr = &out, &out
+		err := cmd.Run()
+		if err == nil {
+			err = os.Rename(tmp, compiled.Binary)
+		}
+		if err != nil {
+			os.Remove(tmp)
+			return &compiledTarget{Err: err, Output: out.Bytes()}
+		}
+		compiled.Output = out.Bytes()
+	}
+
+	if compiled.Symbols, err = ReadSymbolTable(compiled.Binary); err != nil {
+		fmt.Println(t.Dir, "Symbols:", err)
+	}
+	if t.Test {
+		if compiled.Tests, err = t.listTests(compiled.Binary); err != nil {
+			return &compiledTarget{Err: err, Output: compiled.Output}
+		}
+	}
+	if tc.targets == nil {
+		tc.targets = make(map[targetKey]*compiledTarget)
+	}
+	tc.targets[tk] = compiled
+	return compiled
+}
+
+// key returns the content address of the binary of the target compiled with
+// the given Go command and build flags. It covers the toolchain, the build command, the
+// absolute path of the target, which is recorded in the binary, and the
+// contents of the target and of its go.mod.
+func (tc *targetCache) key(ctx context.Context, t target, gocmd string, flags []string) (string, error) {
+	toolchain, err := tc.toolchain(ctx, gocmd)
+	if err != nil {
+		return "", err
+	}
+	dir, err := filepath.Abs(t.Dir)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	cmd := t.compileCmd(ctx, gocmd, t.binaryName(), flags)
+	fmt.Fprintf(h, "toolchain %s\ndir %s\nargs %q\n", toolchain, dir, cmd.Args[1:])
+
+	hashFile := func(name string) error {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		fmt.Fprintf(h, "file %s\n", name)
+		_, err = io.Copy(h, f)
+		return err
+	}
+
+	// The target may depend on the module that contains it.
+	for mod := dir; ; mod = filepath.Dir(mod) {
+		if _, err := os.Stat(filepath.Join(mod, "go.mod")); err == nil {
+			for _, name := range []string{"go.mod", "go.sum"} {
+				if err := hashFile(filepath.Join(mod, name)); err != nil && !os.IsNotExist(err) {
+					return "", err
+				}
+			}
+			break
+		}
+		if mod == filepath.Dir(mod) {
+			break
+		}
+	}
+
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		// Skip binaries from earlier versions of the harness.
+		if rel, _ := filepath.Rel(dir, p); rel == "main" || rel == testBinary {
+			return nil
+		}
+		return hashFile(p)
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// toolchain identifies the toolchain of the Go command by its version, its
+// target platform and the build IDs of the standard library, which change
+// whenever the runtime is modified.
+func (tc *targetCache) toolchain(ctx context.Context, gocmd string) (string, error) {
+	if id, ok := tc.toolchains[gocmd]; ok {
+		return id, nil
+	}
+
+	h := sha256.New()
+	for _, args := range [][]string{
+		{"version"},
+		{"env", "GOOS", "GOARCH", "GOFLAGS", "GOEXPERIMENT", "CGO_ENABLED"},
+		{"list", "-export", "-deps", "-f", "{{.ImportPath}} {{.Export}}", "std"},
+	} {
+		cmd := exec.CommandContext(ctx, gocmd, args...)
+		// Outside of any module.
+		cmd.Dir = os.TempDir()
+		out, err := cmd.Output()
+		if err != nil {
+			if ee, ok := err.(*exec.ExitError); ok {
+				err = fmt.Errorf("%s %s: %w\n%s", gocmd, strings.Join(args, " "), err, ee.Stderr)
+			}
+			return "", err
+		}
+		h.Write(out)
+	}
+
+	if tc.toolchains == nil {
+		tc.toolchains = make(map[string]string)
+	}
+	tc.toolchains[gocmd] = hex.EncodeToString(h.Sum(nil))
+	return tc.toolchains[gocmd], nil
+}
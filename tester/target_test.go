@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const targetTestFile = `package pkg
+
+import "testing"
+
+func f(x int) { select {} }
+
+func TestA(t *testing.T) {
+	go func() {
+		select {} // deadlocks: 1
+	}()
+}
+
+func TestB(t *testing.T) {
+	// deadlocks: 1
+	go f(1)
+}
+
+func BenchmarkC(b *testing.B) {}
+`
+
+func TestTargetCacheTestPackage(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/my.pkg\n\ngo 1.22\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pkg_test.go"), []byte(targetTestFile), 0o644))
+
+	cache := &targetCache{Dir: t.TempDir()}
+	tgt := target{Dir: dir, Test: true}
+	compiled := cache.compile(tgt, "go", nil)
+	require.NoError(t, compiled.Err, string(compiled.Output))
+	require.Equal(t, testBinary, filepath.Base(compiled.Binary))
+	require.Equal(t, []string{"TestA", "TestB"}, compiled.Tests)
+	require.Same(t, compiled, cache.compile(tgt, "go", nil))
+
+	exp, err := getDeadlockExpectations(dir, Config{}, compiled.Symbols)
+	require.NoError(t, err)
+
+	names := func(dls ExpectedDeadlocks) []string {
+		names := make([]string, 0, len(dls.Deadlocks))
+		for _, dl := range dls.Deadlocks {
+			names = append(names, dl.FunctionName)
+		}
+		return names
+	}
+	require.Equal(t, []string{"example.com/my%2epkg.TestA.func1"}, names(exp.ForTest("TestA")))
+	require.Equal(t, []string{"example.com/my%2epkg.TestB.gowrap1"}, names(exp.ForTest("TestB")))
+}
+
+func TestTargetCacheCompilationFailure(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() { undefined() }\n"), 0o644))
+
+	cache := &targetCache{Dir: t.TempDir()}
+	compiled := cache.compile(target{Dir: dir}, "go", nil)
+	require.Error(t, compiled.Err)
+	require.Contains(t, string(compiled.Output), "undefined")
+	require.NotSame(t, compiled, cache.compile(target{Dir: dir}, "go", nil))
+}
+
+func TestTargetCacheReuse(t *testing.T) {
+	dir, cacheDir := t.TempDir(), t.TempDir()
+	main := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(main, []byte("package main\n\nfunc main() {}\n"), 0o644))
+
+	compiled := (&targetCache{Dir: cacheDir}).compile(target{Dir: dir}, "go", nil)
+	require.NoError(t, compiled.Err, string(compiled.Output))
+	require.FileExists(t, compiled.Binary)
+	require.NoFileExists(t, filepath.Join(dir, "main"))
+	info, err := os.Stat(compiled.Binary)
+	require.NoError(t, err)
+
+	// Later runs reuse the binary.
+	reused := (&targetCache{Dir: cacheDir}).compile(target{Dir: dir}, "go", nil)
+	require.NoError(t, reused.Err)
+	require.Equal(t, compiled.Binary, reused.Binary)
+	reusedInfo, err := os.Stat(reused.Binary)
+	require.NoError(t, err)
+	require.Equal(t, info.ModTime(), reusedInfo.ModTime())
+
+	// Changes to the target produce another binary.
+	require.NoError(t, os.WriteFile(main, []byte("package main\n\nfunc main() { println() }\n"), 0o644))
+	changed := (&targetCache{Dir: cacheDir}).compile(target{Dir: dir}, "go", nil)
+	require.NoError(t, changed.Err)
+	require.NotEqual(t, compiled.Binary, changed.Binary)
+	require.FileExists(t, changed.Binary)
+}
@@ -0,0 +1,3 @@
+module example.com/test-package
+
+go 1.22
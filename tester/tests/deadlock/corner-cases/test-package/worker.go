@@ -0,0 +1,6 @@
+package worker
+
+// send sends v on ch.
+func send(ch chan<- int, v int) {
+	ch <- v
+}
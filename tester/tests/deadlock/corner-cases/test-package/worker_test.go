@@ -0,0 +1,35 @@
+package worker
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func gc() {
+	time.Sleep(10 * time.Millisecond)
+	runtime.GC()
+	time.Sleep(10 * time.Millisecond)
+}
+
+func TestLeak(t *testing.T) {
+	defer gc()
+
+	ch := make(chan int)
+	for i := 0; i < 10; i++ {
+		// deadlocks: 9 on chan send
+		go send(ch, i)
+	}
+	// Only one sender is received from.
+	<-ch
+}
+
+func TestLiteral(t *testing.T) {
+	defer gc()
+
+	ch := make(chan int)
+	go func() {
+		// deadlocks: 1 on chan receive
+		<-ch
+	}()
+}
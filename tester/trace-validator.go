@@ -5,6 +5,7 @@ import (
 	"go/ast"
 	"go/token"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -185,6 +186,11 @@ type DeadlockMismatch struct {
 	ExpectedDeadlock
 	TraceDeadlock
 	Unexpected bool
+	// WrongWaitReason is set if the expected number of deadlocks was found,
+	// but some of them were blocked for another reason than the annotation
+	// states. The TraceDeadlock then counts those deadlocks, and lists
+	// their wait reasons.
+	WrongWaitReason bool
 }
 
 // DeadlockDifferential indexes the number of correct annotation hits, as well as
@@ -199,42 +205,109 @@ func (dm DeadlockMismatch) String() string {
 	if dm.Unexpected {
 		return fmt.Sprintf("Unexpected DL: %v (%v)", dm.TraceDeadlock.FunctionName, dm.TraceDeadlock.Count)
 	}
+	if dm.WrongWaitReason {
+		return fmt.Sprintf("[Expected: %v on %v; Actual: %v on %v] at %v (%v)",
+			AstString(dm.ExpectedDeadlock.Expression), dm.ExpectedDeadlock.WaitReason,
+			dm.Count, dm.TraceDeadlock.WaitReason, dm.Position, dm.ExpectedDeadlock.FunctionName)
+	}
 	return fmt.Sprintf("[Expected: %v; Actual: %v] at %v (%v)",
 		AstString(dm.ExpectedDeadlock.Expression),
 		dm.Count, dm.Position, dm.ExpectedDeadlock.FunctionName)
 }
 
+// DeadlocksInTrace returns the deadlocks in the trace that match the annotation.
+// Annotations at go statements match the goroutines created there, while other
+// annotations match goroutines by function name.
+func (dl ExpectedDeadlock) DeadlocksInTrace(t Trace) []TraceDeadlock {
+	var deadlocks []TraceDeadlock
+	for _, deadlock := range t.Deadlocks {
+		if dl.GoStatement && deadlock.CreatedAt(dl.Filename, dl.Line) ||
+			!dl.GoStatement && deadlock.FunctionName == dl.FunctionName {
+			deadlocks = append(deadlocks, deadlock)
+		}
+	}
+	return deadlocks
+}
+
+// CheckWaitReason checks that the given deadlocks were blocked for the wait
+// reason stated by the annotation, if any. Otherwise, it returns a
+// TraceDeadlock counting the deadlocks blocked for other reasons, with
+// their distinct wait reasons.
+func (dl ExpectedDeadlock) CheckWaitReason(deadlocks []TraceDeadlock) (TraceDeadlock, bool) {
+	if dl.WaitReason == "" {
+		return TraceDeadlock{}, true
+	}
+	var wrong TraceDeadlock
+	var reasons []string
+	for _, deadlock := range deadlocks {
+		if deadlock.WaitReason == dl.WaitReason {
+			continue
+		}
+		wrong.Count++
+		if !slices.Contains(reasons, deadlock.WaitReason) {
+			reasons = append(reasons, deadlock.WaitReason)
+		}
+	}
+	wrong.WaitReason = strings.Join(reasons, ", ")
+	return wrong, wrong.Count == 0
+}
+
 // CompareWithTrace compares the expected deadlocks with the actual deadlocks reported
 // by the GC. It ensures that all the expected deadlocks (or absence) noted via annotations are found
-// in the trace, and that no unexpected deadlocks are reported.
+// in the trace, for the expected wait reasons, and that no unexpected deadlocks are reported.
 //
 // It produces a set of mismatches for each distinct function name.
 func (dls ExpectedDeadlocks) CompareWithTrace(t Trace) (diff DeadlockDifferential) {
 	diff.Mismatches = make([]DeadlockMismatch, 0, len(dls.Deadlocks)+len(t.Deadlocks))
 
 	visitedFunctions := make(map[string]struct{})
+	visitedGoStatements := make([]ExpectedDeadlock, 0, len(dls.Deadlocks))
 	for _, dl := range dls.Deadlocks {
-		dlsAtF := t.DeadlocksAtFunction(dl.FunctionName)
-		visitedFunctions[dl.FunctionName] = struct{}{}
-		if dl.CompareWithTraceValue(dlsAtF) {
-			if dl.DeadlockShouldBeFound() {
-				diff.CorrectDeadlockFound++
-			} else {
-				diff.CorrectNoDeadlockFound++
-			}
+		deadlocks := dl.DeadlocksInTrace(t)
+		if dl.GoStatement {
+			visitedGoStatements = append(visitedGoStatements, dl)
+		} else {
+			visitedFunctions[dl.FunctionName] = struct{}{}
+		}
+		if !dl.CompareWithTraceValue(len(deadlocks)) {
+			diff.Mismatches = append(diff.Mismatches, DeadlockMismatch{
+				ExpectedDeadlock: dl,
+				TraceDeadlock: TraceDeadlock{
+					Count: len(deadlocks),
+				},
+			})
 			continue
 		}
-		diff.Mismatches = append(diff.Mismatches, DeadlockMismatch{
-			ExpectedDeadlock: dl,
-			TraceDeadlock: TraceDeadlock{
-				Count: dlsAtF,
-			},
-		})
+		if wrong, ok := dl.CheckWaitReason(deadlocks); !ok {
+			diff.Mismatches = append(diff.Mismatches, DeadlockMismatch{
+				ExpectedDeadlock: dl,
+				TraceDeadlock:    wrong,
+				WrongWaitReason:  true,
+			})
+			continue
+		}
+		if dl.DeadlockShouldBeFound() {
+			diff.CorrectDeadlockFound++
+		} else {
+			diff.CorrectNoDeadlockFound++
+		}
+	}
+
+	visited := func(deadlock TraceDeadlock) bool {
+		if _, ok := visitedFunctions[deadlock.FunctionName]; ok {
+			return true
+		}
+		for _, dl := range visitedGoStatements {
+			if deadlock.CreatedAt(dl.Filename, dl.Line) {
+				return true
+			}
+		}
+		return false
 	}
 
 	unexpectedVisited := make(map[string]int)
 	for _, deadlock := range t.Deadlocks {
-		if _, ok := visitedFunctions[deadlock.FunctionName]; !ok {
+		if !visited(deadlock) {
 			if num, ok := unexpectedVisited[deadlock.FunctionName]; !ok {
 				unexpectedVisited[deadlock.FunctionName] = 1
 			} else {
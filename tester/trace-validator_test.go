@@ -340,6 +340,67 @@ func TestCompareWithTrace(t *testing.T) {
 		require.Equal(t, 1, diff.CorrectDeadlockFound)
 		require.Empty(t, r.Diff.Mismatches)
 	})
+
+	t.Run("deadlocks matched by creation site", func(t *testing.T) {
+		created := func(line int) TraceDeadlock {
+			return TraceDeadlock{
+				FunctionName: "main.f",
+				CreatedBy:    TraceFrame{Function: "main.main", File: "/app/main.go", Line: line},
+			}
+		}
+		dl := ExpectedDeadlock{
+			Position:     token.Position{Filename: "/app/main.go", Line: 10},
+			FunctionName: "main.f",
+			GoStatement:  true,
+			Expression: &ast.BasicLit{
+				Kind:  token.INT,
+				Value: "2",
+			},
+		}
+		dls := ExpectedDeadlocks{
+			Deadlocks: []ExpectedDeadlock{dl},
+		}
+
+		diff := dls.CompareWithTrace(Trace{
+			Deadlocks: []TraceDeadlock{created(10), created(10), created(12)},
+		})
+		require.Equal(t, 1, diff.CorrectDeadlockFound)
+		require.Len(t, diff.Mismatches, 1)
+		require.Equal(t, "Unexpected DL: main.f (1)", diff.Mismatches[0].String())
+	})
+
+	t.Run("deadlocks with wrong wait reason", func(t *testing.T) {
+		dl := ExpectedDeadlock{
+			FunctionName: "main.f",
+			Expression: &ast.BasicLit{
+				Kind:  token.INT,
+				Value: "3",
+			},
+			WaitReason: "chan send",
+		}
+		dls := ExpectedDeadlocks{
+			Deadlocks: []ExpectedDeadlock{dl},
+		}
+
+		trace := Trace{
+			Deadlocks: []TraceDeadlock{
+				{FunctionName: "main.f", WaitReason: "chan send"},
+				{FunctionName: "main.f", WaitReason: "chan receive"},
+				{FunctionName: "main.f", WaitReason: "select"},
+			},
+		}
+		diff := dls.CompareWithTrace(trace)
+		require.Zero(t, diff.CorrectDeadlockFound)
+		require.Len(t, diff.Mismatches, 1)
+		require.True(t, diff.Mismatches[0].WrongWaitReason)
+		require.Equal(t, "[Expected: 3 on chan send; Actual: 2 on chan receive, select] at - (main.f)", diff.Mismatches[0].String())
+
+		trace.Deadlocks[1].WaitReason = "chan send"
+		trace.Deadlocks[2].WaitReason = "chan send"
+		diff = dls.CompareWithTrace(trace)
+		require.Equal(t, 1, diff.CorrectDeadlockFound)
+		require.Empty(t, diff.Mismatches)
+	})
 }
 
 func TestTraceHasExceptions(t *testing.T) {
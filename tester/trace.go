@@ -4,6 +4,8 @@ import (
 	"bufio"
 	"errors"
 	"io"
+	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 )
@@ -19,6 +21,27 @@ const (
 
 	// This is the prefix to the final message, which contains the number of goroutines.
 	finalGos = "Final goroutine count: "
+
+	// This is the prefix to the lock owner printed after the traceback of
+	// a goroutine deadlocked on a lock.
+	heldBy = "held by goroutine "
+
+	// This is the header of the reports of the race detector.
+	dataRace = "WARNING: DATA RACE"
+)
+
+var (
+	// The wait reason of a deadlocked goroutine, following its function name
+	// in the "partial deadlock!" line.
+	headerWaitReason = regexp.MustCompile(` \[([^\[\]]*)\]( |$)`)
+	// A function in a traceback, with its arguments.
+	tracebackFunction = regexp.MustCompile(`^(\S+)\(.*\)$`)
+	// The source position of a function in a traceback, optionally followed
+	// by the PC offset and frame information.
+	tracebackPosition = regexp.MustCompile(`^\t(.+):([0-9]+)(?: .*)?$`)
+	// The trailer of a traceback, naming the function executing the go statement
+	// that created the goroutine, and the creating goroutine.
+	tracebackCreatedBy = regexp.MustCompile(`^created by (\S+)(?: in goroutine ([0-9]+))?$`)
 )
 
 // TraceDeadlock represents a deadlock that occurred in the trace,
@@ -26,6 +49,27 @@ const (
 type TraceDeadlock struct {
 	FunctionName string
 	Count        int
+
+	// Goid is the ID of the deadlocked goroutine.
+	Goid int
+	// WaitReason is the reason the deadlocked goroutine was blocked for,
+	// e.g., "chan send" or "sync.WaitGroup.Wait".
+	WaitReason string
+	// Stack is the traceback of the deadlocked goroutine, innermost frame first.
+	Stack []TraceFrame
+	// CreatedBy is the go statement that created the deadlocked goroutine,
+	// in the function executing it. It is zero if the traceback has no
+	// "created by" trailer.
+	CreatedBy TraceFrame
+	// CreatorGoid is the ID of the goroutine that created the deadlocked goroutine.
+	CreatorGoid int
+}
+
+// TraceFrame is a frame of a traceback.
+type TraceFrame struct {
+	Function string
+	File     string
+	Line     int
 }
 
 // Trace represents a collection of TraceDeadlocks that occured in the trace.
@@ -33,6 +77,8 @@ type Trace struct {
 	Deadlocks     []TraceDeadlock
 	GCMessages    []gcTrace
 	NumGoroutines int
+	// Races is the number of data races reported by the race detector.
+	Races int
 }
 
 // GCPerf represents the performance metrics of the garbage collector.
@@ -53,24 +99,43 @@ type GCPerf struct {
 //
 // The deadlocks are identified by the presence of the string with the format:
 //
-//	"partial deadlock! goroutine <goid>: <function name>"
+//	"partial deadlock! goroutine <goid>: <function name> [<wait reason>]"
+//
+// followed by the traceback of the goroutine, which ends with the
+// "created by <function> in goroutine <goid>" trailer and the position
+// of the go statement that created it.
 //
-// All function names are collected and added to the Trace.
-// Errors resulting from ill-formed GC messages are aggregated.
+// All function names are collected and added to the Trace, together
+// with the tracebacks. Errors resulting from ill-formed GC messages are
+// aggregated.
 func ExtractTrace(rawTrace []byte) (trace Trace, err error) {
+	// dl is the deadlock whose traceback is being parsed, or nil.
+	var dl *TraceDeadlock
+	// frame is the traceback frame whose position is expected next, or nil.
+	var frame *TraceFrame
 	for _, line := range strings.Split(string(rawTrace), "\n") {
 		switch {
 		case strings.Contains(line, "partial deadlock!"):
+			dl, frame = nil, nil
 			parts1 := strings.Split(line, "partial deadlock! ")
 			if len(parts1) < 2 {
 				continue
 			}
 
+			// Extract the wait reason before stripping type parameters,
+			// which are also enclosed in brackets.
+			var waitReason string
+			if m := headerWaitReason.FindStringSubmatchIndex(parts1[1]); m != nil {
+				waitReason = parts1[1][m[2]:m[3]]
+				parts1[1] = parts1[1][:m[0]] + parts1[1][m[4]:]
+			}
 			part1 := genericParam.ReplaceAllString(parts1[1], "")
 			parts2 := strings.Split(part1, " ")
 			// Will never give an out of bounds exception
 			if len(parts2) > 2 {
-				trace.Deadlocks = append(trace.Deadlocks, TraceDeadlock{FunctionName: parts2[2]})
+				goid, _ := strconv.Atoi(strings.TrimSuffix(parts2[1], ":"))
+				trace.Deadlocks = append(trace.Deadlocks, TraceDeadlock{FunctionName: parts2[2], Goid: goid, WaitReason: waitReason})
+				dl = &trace.Deadlocks[len(trace.Deadlocks)-1]
 			}
 		case gcTraceRe.MatchString(line):
 			if gc, gcErr := ParseGCTrace(line); gcErr == nil {
@@ -80,6 +145,27 @@ func ExtractTrace(rawTrace []byte) (trace Trace, err error) {
 			}
 		case strings.HasPrefix(line, finalGos):
 			trace.NumGoroutines, _ = strconv.Atoi(strings.TrimPrefix(line, finalGos))
+		case strings.HasPrefix(line, dataRace):
+			trace.Races++
+		case dl == nil:
+		case line == "", strings.HasPrefix(line, heldBy):
+			// The traceback ended. The lock owner's stack is not part of it.
+			dl, frame = nil, nil
+		case frame != nil:
+			if m := tracebackPosition.FindStringSubmatch(line); m != nil {
+				frame.File = m[1]
+				frame.Line, _ = strconv.Atoi(m[2])
+			}
+			frame = nil
+		default:
+			if m := tracebackCreatedBy.FindStringSubmatch(line); m != nil {
+				dl.CreatedBy = TraceFrame{Function: genericParam.ReplaceAllString(m[1], "")}
+				dl.CreatorGoid, _ = strconv.Atoi(m[2])
+				frame = &dl.CreatedBy
+			} else if m := tracebackFunction.FindStringSubmatch(line); m != nil {
+				dl.Stack = append(dl.Stack, TraceFrame{Function: genericParam.ReplaceAllString(m[1], "")})
+				frame = &dl.Stack[len(dl.Stack)-1]
+			}
 		}
 	}
 	return
@@ -96,6 +182,31 @@ func (t Trace) DeadlocksAtFunction(name string) int {
 	return count
 }
 
+// DeadlocksCreatedAt returns the number of deadlocks of goroutines created
+// by the go statement at the given source position.
+func (t Trace) DeadlocksCreatedAt(file string, line int) int {
+	count := 0
+	for _, deadlock := range t.Deadlocks {
+		if deadlock.CreatedAt(file, line) {
+			count++
+		}
+	}
+	return count
+}
+
+// CreatedAt returns true if the deadlocked goroutine was created by the go
+// statement at the given source position. Relative paths are resolved
+// against the working directory, as tracebacks contain absolute paths.
+func (dl TraceDeadlock) CreatedAt(file string, line int) bool {
+	if dl.CreatedBy.File == "" || dl.CreatedBy.Line != line {
+		return false
+	}
+	if abs, err := filepath.Abs(file); err == nil {
+		file = abs
+	}
+	return filepath.Clean(dl.CreatedBy.File) == file
+}
+
 // RemoveGoGCTrace removes the Go compiler GC trace from the given trace
 // by discarding all lines before the last line containing startRun.
 func RemoveGoGCTrace(trace io.Reader) []byte {
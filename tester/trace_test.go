@@ -34,7 +34,7 @@ Final goroutine count: 30
 	require.ErrorContains(t, err, "strconv.Atoi: parsing \"badstack80\": invalid syntax")
 	require.ErrorContains(t, err, "strconv.Atoi: parsing \"badheap\": invalid syntax")
 	require.EqualValues(t, Trace{
-		Deadlocks: []TraceDeadlock{{FunctionName: "foo"}},
+		Deadlocks: []TraceDeadlock{{FunctionName: "foo", Goid: 1}},
 		GCMessages: []gcTrace{{
 			cycle:          1,
 			cpuUtilization: 0,
@@ -52,6 +52,61 @@ Final goroutine count: 30
 	}, trace)
 }
 
+func TestExtractTraceTracebacks(t *testing.T) {
+	t1 := []byte(`partial deadlock! goroutine 6: main.(*W[...]).Start.gowrap2 [chan send] Stack size: 2048 bytes
+runtime.gopark(...)
+	/golf/src/runtime/proc.go:402 +0xc8 fp=0x14000123700 sp=0x140001236e0 pc=0x1042feb58
+main.f(...)
+	/app/main.go:8
+created by main.main in goroutine 1
+	/app/main.go:19 +0xe5
+held by goroutine 1 (status waiting, sync.Mutex.Lock), acquired at:
+main.main(...)
+	/app/main.go:14
+
+partial deadlock! goroutine 7: !unnamed goroutine! [select (no cases)] Stack size: 2048 bytes
+main.g()
+	/app/main.go:9 +0x1e
+`)
+	trace, err := ExtractTrace(t1)
+	require.NoError(t, err)
+	require.EqualValues(t, Trace{
+		Deadlocks: []TraceDeadlock{{
+			FunctionName: "main.(*W).Start.gowrap2",
+			Goid:         6,
+			WaitReason:   "chan send",
+			Stack: []TraceFrame{
+				{Function: "runtime.gopark", File: "/golf/src/runtime/proc.go", Line: 402},
+				{Function: "main.f", File: "/app/main.go", Line: 8},
+			},
+			CreatedBy:   TraceFrame{Function: "main.main", File: "/app/main.go", Line: 19},
+			CreatorGoid: 1,
+		}, {
+			FunctionName: "!unnamed",
+			Goid:         7,
+			WaitReason:   "select (no cases)",
+			Stack: []TraceFrame{
+				{Function: "main.g", File: "/app/main.go", Line: 9},
+			},
+		}},
+	}, trace)
+}
+
+func TestDeadlocksCreatedAt(t *testing.T) {
+	trace := Trace{
+		Deadlocks: []TraceDeadlock{
+			{CreatedBy: TraceFrame{File: "/app/main.go", Line: 10}},
+			{CreatedBy: TraceFrame{File: "/app/main.go", Line: 12}},
+			{CreatedBy: TraceFrame{File: "/app/main.go", Line: 10}},
+			{},
+		},
+	}
+
+	require.Equal(t, 2, trace.DeadlocksCreatedAt("/app/main.go", 10))
+	require.Equal(t, 1, trace.DeadlocksCreatedAt("/app/main.go", 12))
+	require.Equal(t, 0, trace.DeadlocksCreatedAt("/app/other.go", 10))
+}
+
 func TestDeadlocksAtFunction(t *testing.T) {
 	trace := Trace{
 		Deadlocks: []TraceDeadlock{
@@ -78,3 +133,24 @@ bar`
 	require.EqualValues(t, withoutStart, RemoveGoGCTrace(strings.NewReader(string(withoutStart))))
 	require.EqualValues(t, startRun+"\n"+withoutStart, RemoveGoGCTrace(strings.NewReader(string(withStart))))
 }
+
+func TestExtractTraceRaces(t *testing.T) {
+	trace, err := ExtractTrace([]byte(`==================
+WARNING: DATA RACE
+Write at 0x00c000012345 by goroutine 7:
+  main.main.func1()
+      /tmp/main.go:10 +0x44
+
+Previous read at 0x00c000012345 by main goroutine:
+  main.main()
+      /tmp/main.go:12 +0x88
+==================
+==================
+WARNING: DATA RACE
+==================
+Found 2 data race(s)
+`))
+	require.NoError(t, err)
+	require.Equal(t, 2, trace.Races)
+	require.Empty(t, trace.Deadlocks)
+}